@@ -13,6 +13,9 @@ type Edge struct {
 	Source string `json:"source"`
 	Target string `json:"target"`
 	Type   Type   `json:"type"`
+	// Branch restricts the edge to a branch of its source condition node: the edge is only
+	// taken when the condition selects that branch.
+	Branch string `json:"branch,omitempty"`
 }
 
 func (e Edge) IsDefault() bool {
@@ -26,3 +29,13 @@ func (e Edge) IsSuccess() bool {
 func (e Edge) IsFailure() bool {
 	return e.Type == TypeFailure
 }
+
+// IsTraversable reports whether the edge should be followed given the outcome of its source node.
+// Failure edges are followed only when the source node failed; success and default edges only
+// when it succeeded.
+func (e Edge) IsTraversable(sourceSucceeded bool) bool {
+	if e.IsFailure() {
+		return !sourceSucceeded
+	}
+	return sourceSucceeded
+}
@@ -0,0 +1,89 @@
+package engine_test
+
+import (
+	"testing"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/engine"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/flow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const conditionFlowJSON = `{
+	"name": "Condition Flow",
+	"version": "1.0",
+	"nodes": [
+		{
+			"id": "check-status",
+			"type": "condition",
+			"data": {
+				"branches": [
+					{"name": "active", "when": [{"value": "{{status}}", "operator": {"type": "equals", "expected": "active"}}]},
+					{"name": "blocked", "when": [{"value": "{{status}}", "operator": {"type": "equals", "expected": "blocked"}}]}
+				],
+				"default": "other"
+			}
+		},
+		{"id": "welcome", "type": "delay", "data": {"duration": 1}},
+		{"id": "send-survey", "type": "delay", "data": {"duration": 1}},
+		{"id": "notify-support", "type": "delay", "data": {"duration": 1}},
+		{"id": "investigate", "type": "delay", "data": {"duration": 1}},
+		{"id": "audit", "type": "delay", "data": {"duration": 1}}
+	],
+	"edges": [
+		{"id": "e1", "source": "check-status", "target": "welcome", "type": "success", "branch": "active"},
+		{"id": "e2", "source": "welcome", "target": "send-survey", "type": "success"},
+		{"id": "e3", "source": "check-status", "target": "notify-support", "type": "success", "branch": "blocked"},
+		{"id": "e4", "source": "check-status", "target": "investigate", "type": "success", "branch": "other"},
+		{"id": "e5", "source": "check-status", "target": "audit", "type": "success"}
+	]
+}`
+
+func TestFlowEngine_Execute_ConditionBranches(t *testing.T) {
+	tests := []struct {
+		status           string
+		expectedExecuted []string
+		expectedSkipped  []string
+	}{
+		{
+			status:           "active",
+			expectedExecuted: []string{"check-status", "welcome", "send-survey", "audit"},
+			expectedSkipped:  []string{"notify-support", "investigate"},
+		},
+		{
+			status:           "blocked",
+			expectedExecuted: []string{"check-status", "notify-support", "audit"},
+			expectedSkipped:  []string{"welcome", "send-survey", "investigate"},
+		},
+		{
+			status:           "deleted",
+			expectedExecuted: []string{"check-status", "investigate", "audit"},
+			expectedSkipped:  []string{"welcome", "send-survey", "notify-support"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.status, func(t *testing.T) {
+				flowInstance, err := flow.ParseFromJSON([]byte(conditionFlowJSON))
+				require.NoError(t, err)
+				require.False(t, flow.HasErrors(flow.Validate(*flowInstance)))
+				flowEngine, err := engine.NewFlowEngine(*flowInstance, nil)
+				require.NoError(t, err)
+
+				result, err := flowEngine.Execute(map[string]interface{}{"status": tt.status})
+
+				require.NoError(t, err)
+				require.True(t, result.Success)
+				assert.Equal(t, tt.expectedSkipped, result.SkippedNodes)
+				executed := make([]string, 0, len(result.ExecutionResults))
+				for _, n := range flowInstance.Nodes {
+					if _, exists := result.ExecutionResults[n.GetID()]; exists {
+						executed = append(executed, n.GetID())
+					}
+				}
+				assert.Equal(t, tt.expectedExecuted, executed)
+			},
+		)
+	}
+}
@@ -1,35 +1,91 @@
 package engine
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
 	"strings"
+	"sync"
 	"time"
+	"unsafe"
 
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/edge"
 	"github.com/nanostack-dev/echopoint-flow-engine/pkg/flow"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/httpclient"
 	"github.com/nanostack-dev/echopoint-flow-engine/pkg/node"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/redact"
 )
 
 type Options struct {
+	// BeforeExecution and AfterExecution are never invoked concurrently,
+	// even when independent nodes run in parallel.
 	BeforeExecution func(n node.AnyNode)
 	AfterExecution  func(n node.AnyNode, result node.AnyExecutionResult)
+	// MaxConcurrency limits how many independent nodes run at the same time.
+	// Zero or a negative value means no limit.
+	MaxConcurrency int
+	// Timeout bounds every execution of the flow, subflows included; nodes still running when it
+	// elapses are cancelled. Zero or a negative value sets no deadline of its own: the run then only
+	// stops early when the context passed to ExecuteContext is done.
+	Timeout time.Duration
+	// HTTPClient configures the HTTP client shared by all request nodes of the engine.
+	HTTPClient httpclient.Config
+	// Flows lists the flows that subflow nodes can reference by name and version.
+	Flows []flow.Flow
+	// LogOutput receives the logs of the engine's runs. Each run logs through its own copy of the
+	// global logger, whose output masks the secrets of that run only. Defaults to the output of the
+	// global zerolog logger.
+	LogOutput io.Writer
+}
+
+// outgoingEdge pairs an edge with its resolved target node.
+type outgoingEdge struct {
+	edge   edge.Edge
+	target node.AnyNode
 }
 
 type FlowEngine struct {
 	flow            flow.Flow
-	nodeEdgeOutput  map[node.AnyNode][]node.AnyNode
+	nodeEdgeOutput  map[node.AnyNode][]outgoingEdge
 	nodeEdgeInput   map[node.AnyNode]int
 	nodeMap         map[string]node.AnyNode
+	nodeOrder       map[node.AnyNode]int
 	beforeExecution func(n node.AnyNode)
 	afterExecution  func(n node.AnyNode, result node.AnyExecutionResult)
+	callbackMutex   sync.Mutex
+	maxConcurrency  int
+	timeout         time.Duration
+	httpClient      *http.Client
+	subflows        map[node.AnyNode]*FlowEngine
+	flows           []flow.Flow
+	logOutput       io.Writer
+	parent          *FlowEngine
 }
 
+// NewFlowEngine prepares a flow for execution. Cyclic flows are rejected with a CycleError, and flows
+// for which flow.Validate reports errors with a ValidationError.
 func NewFlowEngine(flowInstance flow.Flow, options *Options) (*FlowEngine, error) {
-	nodeMap := make(map[string]node.AnyNode, len(flowInstance.Nodes))
-	nodeEdgeOutput := make(map[node.AnyNode][]node.AnyNode)
-	nodeEdgeInput := make(map[node.AnyNode]int)
+	return newFlowEngine(flowInstance, options, nil)
+}
+
+// newFlowEngine creates an engine; parent is the engine running the flow as a subflow, if any.
+func newFlowEngine(flowInstance flow.Flow, options *Options, parent *FlowEngine) (*FlowEngine, error) {
+	engine := &FlowEngine{
+		flow:           flowInstance,
+		parent:         parent,
+		nodeEdgeOutput: make(map[node.AnyNode][]outgoingEdge),
+		nodeEdgeInput:  make(map[node.AnyNode]int),
+		nodeMap:        make(map[string]node.AnyNode, len(flowInstance.Nodes)),
+		nodeOrder:      make(map[node.AnyNode]int, len(flowInstance.Nodes)),
+		subflows:       make(map[node.AnyNode]*FlowEngine),
+	}
 
 	log.Debug().
 		Str("flowName", flowInstance.Name).
@@ -38,93 +94,205 @@ func NewFlowEngine(flowInstance flow.Flow, options *Options) (*FlowEngine, error
 		Int("edgeCount", len(flowInstance.Edges)).
 		Msg("Initializing flow engine")
 
-	for _, nodeInstance := range flowInstance.Nodes {
-		nodeMap[nodeInstance.GetID()] = nodeInstance
-		nodeEdgeInput[nodeInstance] = 0
-		nodeEdgeOutput[nodeInstance] = nil
-		log.Debug().
+	if err := engine.registerGraph(); err != nil {
+		return nil, err
+	}
+
+	if cycles := flow.FindCycles(flowInstance.Nodes, flowInstance.Edges); len(cycles) > 0 {
+		err := &CycleError{Path: cycles[0]}
+		log.Error().
+			Str("flowName", flowInstance.Name).
+			Strs("cyclePath", err.Path).
+			Int("cycleCount", len(cycles)).
+			Err(err).
+			Msg("Failed to initialize flow engine: cycle detected")
+		return nil, err
+	}
+
+	if diagnostics := flow.Validate(flowInstance); flow.HasErrors(diagnostics) {
+		err := &ValidationError{Diagnostics: diagnostics}
+		log.Error().
+			Str("flowName", flowInstance.Name).
+			Err(err).
+			Msg("Failed to initialize flow engine: invalid flow")
+		return nil, err
+	}
+
+	var httpConfig httpclient.Config
+	if options != nil {
+		engine.beforeExecution = options.BeforeExecution
+		engine.afterExecution = options.AfterExecution
+		engine.maxConcurrency = options.MaxConcurrency
+		engine.timeout = options.Timeout
+		engine.flows = options.Flows
+		engine.logOutput = options.LogOutput
+		httpConfig = options.HTTPClient
+	}
+
+	if parent != nil {
+		engine.httpClient = parent.httpClient
+	} else {
+		httpClient, err := httpclient.New(httpConfig)
+		if err != nil {
+			err = fmt.Errorf("invalid HTTP client configuration: %w", err)
+			log.Error().
+				Str("flowName", flowInstance.Name).
+				Err(err).
+				Msg("Failed to initialize flow engine: invalid HTTP client configuration")
+			return nil, err
+		}
+		engine.httpClient = httpClient
+	}
+
+	if err := engine.compileSubflows(); err != nil {
+		log.Error().
 			Str("flowName", flowInstance.Name).
+			Err(err).
+			Msg("Failed to initialize flow engine: invalid subflow")
+		return nil, err
+	}
+
+	log.Info().
+		Str("flowName", flowInstance.Name).
+		Str("flowVersion", flowInstance.Version).
+		Int("nodeCount", len(flowInstance.Nodes)).
+		Int("edgeCount", len(flowInstance.Edges)).
+		Msg("Flow engine initialized successfully")
+
+	return engine, nil
+}
+
+// registerGraph indexes the nodes of the flow and resolves the endpoints of its edges.
+func (engine *FlowEngine) registerGraph() error {
+	for i, nodeInstance := range engine.flow.Nodes {
+		engine.nodeMap[nodeInstance.GetID()] = nodeInstance
+		engine.nodeOrder[nodeInstance] = i
+		engine.nodeEdgeInput[nodeInstance] = 0
+		engine.nodeEdgeOutput[nodeInstance] = nil
+		log.Debug().
+			Str("flowName", engine.flow.Name).
 			Str("nodeID", nodeInstance.GetID()).
 			Str("nodeType", string(nodeInstance.GetType())).
 			Msg("Registered node")
 	}
 
-	for _, edge := range flowInstance.Edges {
-		sourceNode := nodeMap[edge.Source]
-		targetNode := nodeMap[edge.Target]
+	for _, flowEdge := range engine.flow.Edges {
+		sourceNode := engine.nodeMap[flowEdge.Source]
+		targetNode := engine.nodeMap[flowEdge.Target]
 		if sourceNode == nil {
 			err := fmt.Errorf(
-				"source node %s not found in edge to node %s", edge.Source,
-				edge.Target,
+				"source node %s not found in edge to node %s", flowEdge.Source,
+				flowEdge.Target,
 			)
 			log.Error().
-				Str("flowName", flowInstance.Name).
-				Str("edgeID", edge.ID).
-				Str("sourceNodeID", edge.Source).
-				Str("targetNodeID", edge.Target).
+				Str("flowName", engine.flow.Name).
+				Str("edgeID", flowEdge.ID).
+				Str("sourceNodeID", flowEdge.Source).
+				Str("targetNodeID", flowEdge.Target).
 				Err(err).
 				Msg("Failed to initialize flow engine: source node not found")
-			return nil, err
+			return err
 		}
 		if targetNode == nil {
 			err := fmt.Errorf(
-				"target node %s not found in edge to node %s", edge.Target,
-				edge.Source,
+				"target node %s not found in edge to node %s", flowEdge.Target,
+				flowEdge.Source,
 			)
 			log.Error().
-				Str("flowName", flowInstance.Name).
-				Str("edgeID", edge.ID).
-				Str("sourceNodeID", edge.Source).
-				Str("targetNodeID", edge.Target).
+				Str("flowName", engine.flow.Name).
+				Str("edgeID", flowEdge.ID).
+				Str("sourceNodeID", flowEdge.Source).
+				Str("targetNodeID", flowEdge.Target).
 				Err(err).
 				Msg("Failed to initialize flow engine: target node not found")
-			return nil, err
+			return err
 		}
-		nodeEdgeOutput[sourceNode] = append(nodeEdgeOutput[sourceNode], targetNode)
-		nodeEdgeInput[targetNode]++
+		engine.nodeEdgeOutput[sourceNode] = append(
+			engine.nodeEdgeOutput[sourceNode], outgoingEdge{edge: flowEdge, target: targetNode},
+		)
+		engine.nodeEdgeInput[targetNode]++
 		log.Debug().
-			Str("flowName", flowInstance.Name).
-			Str("edgeID", edge.ID).
-			Str("sourceNodeID", edge.Source).
-			Str("targetNodeID", edge.Target).
-			Str("edgeType", string(edge.Type)).
+			Str("flowName", engine.flow.Name).
+			Str("edgeID", flowEdge.ID).
+			Str("sourceNodeID", flowEdge.Source).
+			Str("targetNodeID", flowEdge.Target).
+			Str("edgeType", string(flowEdge.Type)).
 			Msg("Registered edge")
 	}
+	return nil
+}
 
-	var beforeExecution func(n node.AnyNode)
-	var afterExecution func(n node.AnyNode, result node.AnyExecutionResult)
-	if options != nil {
-		if options.BeforeExecution != nil {
-			beforeExecution = options.BeforeExecution
-		}
-		if options.AfterExecution != nil {
-			afterExecution = options.AfterExecution
-		}
+// Execute runs the flow without external cancellation. See ExecuteContext.
+func (engine *FlowEngine) Execute(initialInputs map[string]interface{}) (
+	*node.FlowExecutionResult, error,
+) {
+	return engine.ExecuteContext(context.Background(), initialInputs)
+}
+
+// ExecuteContext runs the flow, propagating cancellation and deadlines of ctx into every node.
+// When ctx is cancelled, in-flight nodes are interrupted and reported in CancelledNodes,
+// and nodes that were never started are reported in NotStartedNodes.
+func (engine *FlowEngine) ExecuteContext(ctx context.Context, initialInputs map[string]interface{}) (
+	*node.FlowExecutionResult, error,
+) {
+	if engine.timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, engine.timeout)
+		defer cancelTimeout()
 	}
 
-	log.Info().
-		Str("flowName", flowInstance.Name).
-		Str("flowVersion", flowInstance.Version).
-		Int("nodeCount", len(flowInstance.Nodes)).
-		Int("edgeCount", len(flowInstance.Edges)).
-		Msg("Flow engine initialized successfully")
+	// Secrets are masked in the logs of the run while it is in progress, and in the result once it is over
+	redactor := redact.New()
+	logOutput := engine.logOutput
+	if logOutput == nil {
+		logOutput = logOutputOf(log.Logger)
+	}
+	runLogger := log.Output(redact.NewWriter(logOutput, redactor))
+	scope := &runScope{
+		tokenCache: node.NewTokenCache(),
+		redactor:   redactor,
+		logger:     &runLogger,
+	}
 
-	return &FlowEngine{
-		flowInstance,
-		nodeEdgeOutput,
-		nodeEdgeInput,
-		nodeMap,
-		beforeExecution,
-		afterExecution,
-	}, nil
+	result, err := engine.run(ctx, initialInputs, scope)
+	result.Redact(scope.redactor)
+	return result, err
 }
 
-func (engine *FlowEngine) Execute(initialInputs map[string]interface{}) (
+// logOutputOf returns the output of l. zerolog has no accessor for it, yet runs must keep logging
+// to the output configured on the global logger, only wrapped to mask their secrets.
+func logOutputOf(l zerolog.Logger) io.Writer {
+	field := reflect.ValueOf(&l).Elem().FieldByName("w")
+	if !field.IsValid() {
+		return os.Stderr
+	}
+	//nolint:gosec // reads the unexported output field of zerolog.Logger, see above
+	output, ok := reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem().Interface().(io.Writer)
+	if !ok || output == nil {
+		// A zero Logger has no output and logs nothing
+		return io.Discard
+	}
+	return output
+}
+
+// runScope holds what the nodes of a flow run share, including the nodes of its subflows.
+type runScope struct {
+	tokenCache *node.TokenCache
+	redactor   *redact.Redactor
+	logger     *zerolog.Logger
+}
+
+// run executes the flow within a run scope. Subflows are run the same way, sharing the scope
+// of the enclosing run.
+func (engine *FlowEngine) run(ctx context.Context, initialInputs map[string]interface{}, scope *runScope) (
 	*node.FlowExecutionResult, error,
 ) {
 	startTime := time.Now()
+	for _, secret := range engine.secretInputValues(initialInputs) {
+		scope.redactor.Add(secret)
+	}
 
-	log.Info().
+	scope.logger.Info().
 		Str("flowName", engine.flow.Name).
 		Str("flowVersion", engine.flow.Version).
 		Int("totalNodes", len(engine.flow.Nodes)).
@@ -132,15 +300,33 @@ func (engine *FlowEngine) Execute(initialInputs map[string]interface{}) (
 		Msg("Starting flow execution")
 
 	result := &node.FlowExecutionResult{
+		SchemaVersion:    node.ResultSchemaVersion,
 		ExecutionResults: make(map[string]node.AnyExecutionResult),
-		FinalOutputs:     make(map[string]interface{}),
+		NodeStatuses:     make(map[string]node.NodeStatus),
 		Success:          false,
 	}
+	// Flows declaring outputs only expose those, not the outputs of their nodes
+	if len(engine.flow.Outputs) == 0 {
+		result.FinalOutputs = make(map[string]interface{})
+	}
+
+	initialInputs, err := engine.flow.PrepareInputs(initialInputs)
+	if err != nil {
+		failRun(result, err, node.ErrorCodeInvalidInput, startTime)
+		scope.logger.Error().
+			Str("flowName", engine.flow.Name).
+			Err(err).
+			Msg("Flow execution failed: invalid inputs")
+		return result, err
+	}
+	// Defaults of secret inputs are only known now
+	for _, secret := range engine.secretInputValues(initialInputs) {
+		scope.redactor.Add(secret)
+	}
 
 	if len(engine.nodeEdgeInput) == 0 {
-		result.Error = errors.New("no nodes to execute")
-		result.DurationMS = time.Since(startTime).Milliseconds()
-		log.Error().
+		failRun(result, errors.New("no nodes to execute"), node.ErrorCodeInvalidFlow, startTime)
+		scope.logger.Error().
 			Str("flowName", engine.flow.Name).
 			Err(result.Error).
 			Int64("durationMS", result.DurationMS).
@@ -148,57 +334,133 @@ func (engine *FlowEngine) Execute(initialInputs map[string]interface{}) (
 		return result, result.Error
 	}
 
-	if err := engine.executeNodes(initialInputs, result, startTime); err != nil {
+	if err = engine.executeNodes(ctx, initialInputs, result, scope, startTime); err != nil {
 		return result, err
 	}
 
 	return result, nil
 }
 
-// validateInputs checks that all required inputs for a node are available in allOutputs.
-func (engine *FlowEngine) validateInputs(
-	nodeToExecute node.AnyNode, allOutputs map[string]map[string]interface{},
-) error {
-	for _, inputKey := range nodeToExecute.InputSchema() {
-		sourceNodeID, outputKey, err := parseDataRef(inputKey)
+// failRun records why a run failed in its result.
+func failRun(result *node.FlowExecutionResult, err error, code node.ErrorCode, startTime time.Time) {
+	errMsg := err.Error()
+	result.Error = err
+	result.ErrorMsg = &errMsg
+	result.ErrorCode = &code
+	result.DurationMS = time.Since(startTime).Milliseconds()
+}
+
+// compileSubflows prepares an engine for the child flow of every container node, so that invalid
+// subflows and unknown flow references are reported when the flow is loaded. Subflow engines share
+// the HTTP client, the concurrency limit and the registered flows of the enclosing engine; the flow
+// timeout applies to the whole run.
+func (engine *FlowEngine) compileSubflows() error {
+	for _, n := range engine.flow.Nodes {
+		childFlow, hasChild, err := engine.childFlow(n)
 		if err != nil {
-			log.Error().
-				Str("flowName", engine.flow.Name).
-				Str("nodeID", nodeToExecute.GetID()).
-				Str("inputKey", inputKey).
-				Err(err).
-				Msg("Invalid input reference")
-			return fmt.Errorf(
-				"node %s: invalid input reference '%s': %w", nodeToExecute.GetID(), inputKey, err,
-			)
+			return fmt.Errorf("invalid subflow of node '%s': %w", n.GetID(), err)
+		}
+		if !hasChild {
+			continue
 		}
+		child, err := newFlowEngine(
+			childFlow, &Options{MaxConcurrency: engine.maxConcurrency, Flows: engine.flows}, engine,
+		)
+		if err != nil {
+			return fmt.Errorf("invalid subflow of node '%s': %w", n.GetID(), err)
+		}
+		engine.subflows[n] = child
+	}
+	return nil
+}
 
-		sourceOutputs, exists := allOutputs[sourceNodeID]
-		if !exists {
-			log.Warn().
-				Str("flowName", engine.flow.Name).
-				Str("nodeID", nodeToExecute.GetID()).
-				Str("sourceNodeID", sourceNodeID).
-				Str("inputKey", inputKey).
-				Msg("Source node not executed yet")
-			return fmt.Errorf(
-				"node %s: source node '%s' not executed yet (required for input '%s')",
-				nodeToExecute.GetID(), sourceNodeID, inputKey,
-			)
+// childFlow returns the flow run by a container node: either the flow it references or the
+// subflow it embeds, which inherits the settings of the enclosing flow.
+func (engine *FlowEngine) childFlow(n node.AnyNode) (flow.Flow, bool, error) {
+	var subflow *node.Subflow
+	if container, isContainer := n.(node.Container); isContainer {
+		subflow = container.GetSubflow()
+	}
+	var reference *node.FlowReference
+	if referrer, isReferrer := n.(node.Referrer); isReferrer {
+		reference = referrer.GetFlowReference()
+	}
+
+	switch {
+	case subflow != nil && reference != nil:
+		return flow.Flow{}, false, errors.New("a node cannot both reference and embed a flow")
+	case reference != nil:
+		referenced, err := engine.referencedFlow(*reference)
+		return referenced, err == nil, err
+	case subflow != nil:
+		return flow.Flow{
+			Name:     engine.flow.Name + "/" + n.GetID(),
+			Version:  engine.flow.Version,
+			Nodes:    subflow.Nodes,
+			Edges:    subflow.Edges,
+			Outputs:  subflow.Outputs,
+			Settings: engine.flow.Settings,
+		}, true, nil
+	}
+	if _, isContainer := n.(node.Container); isContainer {
+		return flow.Flow{}, false, errors.New("the node must either reference or embed a flow")
+	}
+	return flow.Flow{}, false, nil
+}
+
+// referencedFlow looks a flow up in the registered flows. A flow cannot run itself, directly
+// or through other flows.
+func (engine *FlowEngine) referencedFlow(reference node.FlowReference) (flow.Flow, error) {
+	for ancestor := engine; ancestor != nil; ancestor = ancestor.parent {
+		if ancestor.flow.Name == reference.Name && ancestor.flow.Version == reference.Version {
+			return flow.Flow{}, fmt.Errorf("flow %s runs itself", reference)
+		}
+	}
+	for _, registered := range engine.flows {
+		if registered.Name == reference.Name && registered.Version == reference.Version {
+			return registered, nil
 		}
+	}
+	return flow.Flow{}, fmt.Errorf("flow %s is not registered", reference)
+}
 
-		_, exists = sourceOutputs[outputKey]
-		if !exists {
-			log.Warn().
+// secretInputValues returns the values of the initial inputs listed in the flow's SecretInputs or
+// declared as secret. A dotted name such as "credentials.apiKey" designates a field of a map input.
+func (engine *FlowEngine) secretInputValues(initialInputs map[string]interface{}) []interface{} {
+	names := engine.flow.SecretInputNames()
+	values := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		if value, exists := initialInputs[name]; exists {
+			values = append(values, value)
+			continue
+		}
+		var current interface{} = initialInputs
+		for _, key := range strings.Split(name, ".") {
+			fields, isMap := current.(map[string]interface{})
+			if !isMap {
+				current = nil
+				break
+			}
+			current = fields[key]
+		}
+		if current != nil {
+			values = append(values, current)
+		}
+	}
+	return values
+}
+
+// validateInputs checks that all required inputs for a node are available in allOutputs.
+func (engine *FlowEngine) validateInputs(nodeToExecute node.AnyNode, state *executionState) error {
+	for _, inputKey := range nodeToExecute.InputSchema() {
+		if _, err := engine.lookupInput(inputKey, state.allOutputs); err != nil {
+			state.scope.logger.Warn().
 				Str("flowName", engine.flow.Name).
 				Str("nodeID", nodeToExecute.GetID()).
-				Str("sourceNodeID", sourceNodeID).
-				Str("outputKey", outputKey).
-				Msg("Output not found in source node")
-			return fmt.Errorf(
-				"node %s: output '%s' not found in source node '%s'",
-				nodeToExecute.GetID(), outputKey, sourceNodeID,
-			)
+				Str("inputKey", inputKey).
+				Err(err).
+				Msg("Input not available")
+			return fmt.Errorf("node %s: %w", nodeToExecute.GetID(), err)
 		}
 	}
 	return nil
@@ -211,9 +473,7 @@ func (engine *FlowEngine) assembleInputs(
 	inputs := make(map[string]interface{})
 
 	for _, inputKey := range nodeToExecute.InputSchema() {
-		sourceNodeID, outputKey, _ := parseDataRef(inputKey)
-		sourceOutputs := allOutputs[sourceNodeID]
-		value := sourceOutputs[outputKey]
+		value, _ := engine.lookupInput(inputKey, allOutputs)
 		// Store with full reference key (e.g., "create-user.userId")
 		inputs[inputKey] = value
 	}
@@ -221,6 +481,43 @@ func (engine *FlowEngine) assembleInputs(
 	return inputs
 }
 
+// lookupInput resolves an input reference. "nodeId.outputKey" refers to the output of a node;
+// any other reference is an initial input, either a key of its own ("config.baseUrl")
+// or a field of a map input ("config" with a "baseUrl" field).
+func (engine *FlowEngine) lookupInput(
+	inputKey string, allOutputs map[string]map[string]interface{},
+) (interface{}, error) {
+	sourceNodeID, outputKey, err := parseDataRef(inputKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid input reference '%s': %w", inputKey, err)
+	}
+
+	if _, isNode := engine.nodeMap[sourceNodeID]; isNode {
+		sourceOutputs, exists := allOutputs[sourceNodeID]
+		if !exists {
+			return nil, fmt.Errorf(
+				"source node '%s' not executed yet (required for input '%s')", sourceNodeID, inputKey,
+			)
+		}
+		value, exists := sourceOutputs[outputKey]
+		if !exists {
+			return nil, fmt.Errorf("output '%s' not found in source node '%s'", outputKey, sourceNodeID)
+		}
+		return value, nil
+	}
+
+	initialInputs := allOutputs[""]
+	if value, exists := initialInputs[inputKey]; exists {
+		return value, nil
+	}
+	if parent, isMap := initialInputs[sourceNodeID].(map[string]interface{}); isMap && sourceNodeID != "" {
+		if value, exists := parent[outputKey]; exists {
+			return value, nil
+		}
+	}
+	return nil, fmt.Errorf("output '%s' not found in source node ''", inputKey)
+}
+
 // parseDataRef parses input references in two formats:
 // 1. "nodeId.outputKey" - refers to output from a specific node
 // 2. "variableName" - refers to initial input variable (sourceNodeID will be empty string "").
@@ -242,13 +539,3 @@ func parseDataRef(ref string) (string, string, error) {
 		"invalid reference format, expected 'nodeId.outputKey' or 'variableName', got '%s'", ref,
 	)
 }
-
-// findNodeWithoutInput finds a node that has no remaining input dependencies.
-func (engine *FlowEngine) findNodeWithoutInput(remainingInputs map[node.AnyNode]int) node.AnyNode {
-	for nodeKey, inputCount := range remainingInputs {
-		if inputCount == 0 {
-			return nodeKey
-		}
-	}
-	return nil
-}
@@ -1,7 +1,14 @@
 package engine_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -9,7 +16,11 @@ import (
 	"github.com/nanostack-dev/echopoint-flow-engine/pkg/edge"
 	"github.com/nanostack-dev/echopoint-flow-engine/pkg/engine"
 	"github.com/nanostack-dev/echopoint-flow-engine/pkg/flow"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/httpclient"
 	"github.com/nanostack-dev/echopoint-flow-engine/pkg/node"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/redact"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -67,7 +78,7 @@ func (n *MockNode) Execute(_ node.ExecutionContext) (node.AnyExecutionResult, er
 	if n.shouldError {
 		err = errors.New("mock error")
 		errMsg := err.Error()
-		errCode := "MOCK_ERROR"
+		errCode := node.ErrorCode("MOCK_ERROR")
 		return &node.BaseExecutionResult{
 			NodeID:      n.id,
 			DisplayName: n.id,
@@ -131,7 +142,7 @@ func (n *DataContractMockNode) Execute(ctx node.ExecutionContext) (node.AnyExecu
 		if _, exists := ctx.Inputs[dep]; !exists {
 			err := errors.New("missing required input: " + dep)
 			errMsg := err.Error()
-			errCode := "MISSING_INPUT"
+			errCode := node.ErrorCode("MISSING_INPUT")
 			return &node.BaseExecutionResult{
 				NodeID:      n.id,
 				DisplayName: n.id,
@@ -149,7 +160,7 @@ func (n *DataContractMockNode) Execute(ctx node.ExecutionContext) (node.AnyExecu
 	if n.shouldError {
 		err := errors.New("intentional error in " + n.id)
 		errMsg := err.Error()
-		errCode := "INTENTIONAL_ERROR"
+		errCode := node.ErrorCode("INTENTIONAL_ERROR")
 		return &node.BaseExecutionResult{
 			NodeID:     n.id,
 			NodeType:   n.nodeType,
@@ -311,6 +322,185 @@ func TestFlowEngine_Execute_ParallelFlow(t *testing.T) {
 	)
 }
 
+// concurrencyTrackingNode records how many nodes are executing at the same time.
+type concurrencyTrackingNode struct {
+	MockNode
+
+	running *atomic.Int32
+	peak    *atomic.Int32
+}
+
+func (n *concurrencyTrackingNode) Execute(ctx node.ExecutionContext) (node.AnyExecutionResult, error) {
+	current := n.running.Add(1)
+	defer n.running.Add(-1)
+	for {
+		peak := n.peak.Load()
+		if current <= peak || n.peak.CompareAndSwap(peak, current) {
+			break
+		}
+	}
+	// Long enough for the scheduler to start the next nodes while this one is running
+	time.Sleep(50 * time.Millisecond)
+	return n.MockNode.Execute(ctx)
+}
+
+func TestFlowEngine_Execute_IndependentBranchesRunConcurrently(t *testing.T) {
+	const delayMs = 200
+	delay1 := newDelayNode("delay1", delayMs)
+	delay2 := newDelayNode("delay2", delayMs)
+
+	flowInstance := flow.Flow{
+		Name:    "Concurrent Flow",
+		Nodes:   []node.AnyNode{delay1, delay2},
+		Edges:   []edge.Edge{},
+		Version: "1.0",
+	}
+
+	flowEngine, err := engine.NewFlowEngine(flowInstance, nil)
+	require.NoError(t, err)
+
+	start := time.Now()
+	result, err := flowEngine.Execute(make(map[string]interface{}))
+	elapsed := time.Since(start)
+
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	assert.Len(t, result.ExecutionResults, 2)
+	assert.Less(t, elapsed, 2*delayMs*time.Millisecond, "independent delays should overlap")
+}
+
+func TestFlowEngine_Execute_MaxConcurrency(t *testing.T) {
+	running := &atomic.Int32{}
+	peak := &atomic.Int32{}
+	nodes := make([]node.AnyNode, 0, 4)
+	for _, id := range []string{"a", "b", "c", "d"} {
+		nodes = append(
+			nodes, &concurrencyTrackingNode{
+				MockNode: MockNode{id: id, nodeType: node.TypeRequest, shouldPass: true},
+				running:  running,
+				peak:     peak,
+			},
+		)
+	}
+
+	flowInstance := flow.Flow{
+		Name:    "Bounded Flow",
+		Nodes:   nodes,
+		Edges:   []edge.Edge{},
+		Version: "1.0",
+	}
+
+	var afterCalls []string
+	flowEngine, err := engine.NewFlowEngine(
+		flowInstance, &engine.Options{
+			MaxConcurrency: 2,
+			AfterExecution: func(n node.AnyNode, _ node.AnyExecutionResult) {
+				afterCalls = append(afterCalls, n.GetID())
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	result, err := flowEngine.Execute(make(map[string]interface{}))
+
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	assert.Greater(t, peak.Load(), int32(1), "independent nodes should overlap")
+	assert.LessOrEqual(t, peak.Load(), int32(2), "no more than 2 nodes should run at once")
+	assert.ElementsMatch(t, []string{"a", "b", "c", "d"}, afterCalls)
+}
+
+func newDelayNode(id string, durationMs int) *node.DelayNode {
+	return &node.DelayNode{
+		BaseNode: node.BaseNode{ID: id, NodeType: node.TypeDelay},
+		Data:     node.DelayData{Duration: durationMs},
+	}
+}
+
+func TestFlowEngine_ExecuteContext_Cancellation(t *testing.T) {
+	slow := newDelayNode("slow", 5000)
+	after := newDelayNode("after", 0)
+
+	flowInstance := flow.Flow{
+		Name:  "Cancelled Flow",
+		Nodes: []node.AnyNode{slow, after},
+		Edges: []edge.Edge{
+			{ID: "e1", Source: "slow", Target: "after", Type: edge.TypeSuccess},
+		},
+		Version: "1.0",
+	}
+
+	flowEngine, err := engine.NewFlowEngine(flowInstance, nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	result, err := flowEngine.ExecuteContext(ctx, make(map[string]interface{}))
+
+	require.Error(t, err)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.False(t, result.Success)
+	assert.Less(t, time.Since(start), time.Second, "cancellation should interrupt the delay")
+	assert.Equal(t, []string{"slow"}, result.CancelledNodes)
+	assert.Equal(t, []string{"after"}, result.NotStartedNodes)
+	require.NotNil(t, result.ErrorCode)
+	assert.Equal(t, node.ErrorCodeCancelled, *result.ErrorCode)
+	assert.Equal(
+		t, map[string]node.NodeStatus{"slow": node.NodeStatusCancelled, "after": node.NodeStatusNotStarted},
+		result.NodeStatuses,
+	)
+}
+
+func TestFlowEngine_ExecuteContext_FlowTimeout(t *testing.T) {
+	slow := newDelayNode("slow", 5000)
+
+	flowInstance := flow.Flow{
+		Name:    "Timed Out Flow",
+		Nodes:   []node.AnyNode{slow},
+		Edges:   []edge.Edge{},
+		Version: "1.0",
+	}
+
+	flowEngine, err := engine.NewFlowEngine(flowInstance, &engine.Options{Timeout: 50 * time.Millisecond})
+	require.NoError(t, err)
+
+	result, err := flowEngine.Execute(make(map[string]interface{}))
+
+	require.Error(t, err)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.NotNil(t, result.ErrorCode)
+	assert.Equal(t, node.ErrorCodeTimeout, *result.ErrorCode)
+	assert.Equal(t, []string{"slow"}, result.CancelledNodes)
+	delayResult := node.MustAsDelayExecutionResult(result.ExecutionResults["slow"])
+	require.NotNil(t, delayResult.ErrorCode)
+	assert.Equal(t, node.ErrorCodeCancelled, *delayResult.ErrorCode)
+}
+
+func TestFlowEngine_Execute_FailureCancelsInFlightSiblings(t *testing.T) {
+	failing := &MockNode{id: "failing", nodeType: node.TypeRequest, shouldError: true}
+	slow := newDelayNode("slow", 5000)
+
+	flowInstance := flow.Flow{
+		Name:    "Aborted Flow",
+		Nodes:   []node.AnyNode{slow, failing},
+		Edges:   []edge.Edge{},
+		Version: "1.0",
+	}
+
+	flowEngine, err := engine.NewFlowEngine(flowInstance, nil)
+	require.NoError(t, err)
+
+	start := time.Now()
+	result, err := flowEngine.Execute(make(map[string]interface{}))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "mock error")
+	assert.Less(t, time.Since(start), time.Second, "in-flight siblings should be interrupted")
+	assert.Equal(t, []string{"slow"}, result.CancelledNodes)
+}
+
 func TestFlowEngine_Execute_BranchingFlow(t *testing.T) {
 	node1 := &MockNode{id: "node1", nodeType: node.TypeRequest, shouldPass: true}
 	node2 := &MockNode{id: "node2", nodeType: node.TypeRequest, shouldPass: true}
@@ -334,8 +524,78 @@ func TestFlowEngine_Execute_BranchingFlow(t *testing.T) {
 	require.NoError(t, err)
 	require.True(t, result.Success)
 	assert.True(t, node1.executed, "node1 should be executed")
-	assert.True(t, node2.executed, "node2 should be executed")
-	assert.True(t, node3.executed, "node3 should be executed")
+	assert.True(t, node2.executed, "node2 should be executed on success edge")
+	assert.False(t, node3.executed, "node3 should not be executed on failure edge")
+	assert.Equal(t, []string{"node3"}, result.SkippedNodes)
+}
+
+func TestFlowEngine_Execute_FailureEdgeRouting(t *testing.T) {
+	node1 := &MockNode{id: "node1", nodeType: node.TypeRequest, shouldError: true}
+	node2 := &MockNode{id: "node2", nodeType: node.TypeRequest, shouldPass: true}
+	node3 := &MockNode{id: "node3", nodeType: node.TypeRequest, shouldPass: true}
+	cleanup := &MockNode{id: "cleanup", nodeType: node.TypeRequest, shouldPass: true}
+
+	flowInstance := flow.Flow{
+		Name:  "Failure Routing Flow",
+		Nodes: []node.AnyNode{node1, node2, node3, cleanup},
+		Edges: []edge.Edge{
+			{ID: "e1", Source: "node1", Target: "node2", Type: edge.TypeSuccess},
+			{ID: "e2", Source: "node2", Target: "node3", Type: edge.TypeSuccess},
+			{ID: "e3", Source: "node1", Target: "cleanup", Type: edge.TypeFailure},
+		},
+		Version: "1.0",
+	}
+
+	flowEngine, err := engine.NewFlowEngine(flowInstance, &engine.Options{})
+	require.NoError(t, err)
+
+	result, err := flowEngine.Execute(make(map[string]interface{}))
+
+	require.NoError(t, err, "failure handled by a failure edge should not abort the flow")
+	require.True(t, result.Success)
+	assert.True(t, node1.executed, "node1 should be executed")
+	assert.True(t, cleanup.executed, "cleanup should be executed on failure edge")
+	assert.False(t, node2.executed, "node2 should be skipped")
+	assert.False(t, node3.executed, "node3 should be skipped with its parent")
+	assert.Equal(t, []string{"node2", "node3"}, result.SkippedNodes)
+	require.Error(t, result.ExecutionResults["node1"].GetError(), "failed node should keep its error")
+	assert.Equal(
+		t, map[string]node.NodeStatus{
+			"node1":   node.NodeStatusFailed,
+			"node2":   node.NodeStatusSkipped,
+			"node3":   node.NodeStatusSkipped,
+			"cleanup": node.NodeStatusSucceeded,
+		}, result.NodeStatuses,
+	)
+	assert.Nil(t, result.ErrorCode)
+}
+
+func TestFlowEngine_Execute_FallbackMerge(t *testing.T) {
+	primary := &MockNode{id: "primary", nodeType: node.TypeRequest, shouldError: true}
+	fallback := &MockNode{id: "fallback", nodeType: node.TypeRequest, shouldPass: true}
+	next := &MockNode{id: "next", nodeType: node.TypeRequest, shouldPass: true}
+
+	flowInstance := flow.Flow{
+		Name:  "Fallback Flow",
+		Nodes: []node.AnyNode{primary, fallback, next},
+		Edges: []edge.Edge{
+			{ID: "e1", Source: "primary", Target: "next", Type: edge.TypeSuccess},
+			{ID: "e2", Source: "primary", Target: "fallback", Type: edge.TypeFailure},
+			{ID: "e3", Source: "fallback", Target: "next", Type: edge.TypeSuccess},
+		},
+		Version: "1.0",
+	}
+
+	flowEngine, err := engine.NewFlowEngine(flowInstance, &engine.Options{})
+	require.NoError(t, err)
+
+	result, err := flowEngine.Execute(make(map[string]interface{}))
+
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	assert.True(t, fallback.executed, "fallback should be executed")
+	assert.True(t, next.executed, "next should run once any incoming edge is taken")
+	assert.Empty(t, result.SkippedNodes)
 }
 
 func TestFlowEngine_Execute_NodeFailsWithError(t *testing.T) {
@@ -381,18 +641,47 @@ func TestFlowEngine_Execute_NoNodes(t *testing.T) {
 	require.Error(t, err)
 	require.False(t, result.Success)
 	assert.Contains(t, err.Error(), "no nodes to execute")
+	assert.Equal(t, node.ErrorCodeInvalidFlow, *result.ErrorCode)
 }
 
 func TestFlowEngine_Execute_CycleDetection(t *testing.T) {
 	node1 := &MockNode{id: "node1", nodeType: node.TypeRequest, shouldPass: true}
 	node2 := &MockNode{id: "node2", nodeType: node.TypeRequest, shouldPass: true}
+	node3 := &MockNode{id: "node3", nodeType: node.TypeRequest, shouldPass: true}
 
 	flowInstance := flow.Flow{
 		Name:  "Cyclic Flow",
-		Nodes: []node.AnyNode{node1, node2},
+		Nodes: []node.AnyNode{node1, node2, node3},
 		Edges: []edge.Edge{
 			{ID: "e1", Source: "node1", Target: "node2", Type: "success"},
-			{ID: "e2", Source: "node2", Target: "node1", Type: "success"},
+			{ID: "e2", Source: "node2", Target: "node3", Type: "success"},
+			{ID: "e3", Source: "node3", Target: "node2", Type: "success"},
+		},
+		Version: "1.0",
+	}
+
+	flowEngine, err := engine.NewFlowEngine(flowInstance, &engine.Options{})
+
+	require.Error(t, err)
+	assert.Nil(t, flowEngine)
+	var cycleErr *engine.CycleError
+	require.ErrorAs(t, err, &cycleErr)
+	assert.Equal(t, []string{"node2", "node3", "node2"}, cycleErr.Path)
+	assert.Equal(t, "cycle detected: node2 -> node3 -> node2", err.Error())
+}
+
+func TestFlowEngine_Execute_UnreachableNodes(t *testing.T) {
+	login := &MockNode{id: "login", nodeType: node.TypeRequest, shouldError: true}
+	profile := &MockNode{id: "profile", nodeType: node.TypeRequest, shouldPass: true}
+	report := &MockNode{id: "report", nodeType: node.TypeRequest, shouldPass: true}
+
+	flowInstance := flow.Flow{
+		Name:  "Blocked Flow",
+		Nodes: []node.AnyNode{login, profile, report},
+		Edges: []edge.Edge{
+			{ID: "e1", Source: "login", Target: "profile", Type: edge.TypeSuccess},
+			{ID: "e2", Source: "login", Target: "report", Type: edge.TypeSuccess},
+			{ID: "e3", Source: "profile", Target: "report", Type: edge.TypeSuccess},
 		},
 		Version: "1.0",
 	}
@@ -403,8 +692,25 @@ func TestFlowEngine_Execute_CycleDetection(t *testing.T) {
 	result, err := flowEngine.Execute(make(map[string]interface{}))
 
 	require.Error(t, err)
-	require.False(t, result.Success)
-	assert.Contains(t, err.Error(), "cycle detected or unreachable nodes")
+	assert.False(t, result.Success)
+	assert.Contains(t, err.Error(), "mock error")
+	var unreachableErr *engine.UnreachableNodesError
+	require.ErrorAs(t, err, &unreachableErr)
+	assert.Equal(
+		t, []engine.UnreachableNode{
+			{NodeID: "profile", BlockedBy: []string{"login"}},
+			{NodeID: "report", BlockedBy: []string{"login", "profile"}},
+		}, unreachableErr.Nodes,
+	)
+	assert.Equal(t, []string{"profile", "report"}, result.NotStartedNodes)
+	require.NotNil(t, result.ErrorCode)
+	assert.Equal(t, node.ErrorCodeNodeFailed, *result.ErrorCode)
+	assert.Equal(t, err.Error(), *result.ErrorMsg)
+	assert.Equal(
+		t, map[string]node.NodeStatus{
+			"login": node.NodeStatusFailed, "profile": node.NodeStatusNotStarted, "report": node.NodeStatusNotStarted,
+		}, result.NodeStatuses,
+	)
 }
 
 func TestFlowEngine_Execute_WithBeforeAndAfterCallbacks(t *testing.T) {
@@ -490,6 +796,34 @@ func TestDataContract_SimpleDataPassing(t *testing.T) {
 	assert.Equal(t, "user-123", frame2.GetInputs()["create-user.userId"])
 }
 
+// TestDataContract_NestedInitialInput tests references into fields of map-valued initial inputs.
+func TestDataContract_NestedInitialInput(t *testing.T) {
+	consumer := newDataContractMockNode(
+		"consumer", []string{"config.baseUrl", "api.key"}, []string{},
+	)
+
+	flowInstance := flow.Flow{
+		Name:  "Nested Input Test",
+		Nodes: []node.AnyNode{consumer},
+		Edges: []edge.Edge{},
+	}
+
+	flowEngine, err := engine.NewFlowEngine(flowInstance, nil)
+	require.NoError(t, err)
+
+	result, err := flowEngine.Execute(
+		map[string]interface{}{
+			"config":  map[string]interface{}{"baseUrl": "http://localhost"},
+			"api.key": "secret",
+		},
+	)
+
+	require.NoError(t, err)
+	inputs := result.ExecutionResults["consumer"].GetInputs()
+	assert.Equal(t, "http://localhost", inputs["config.baseUrl"])
+	assert.Equal(t, "secret", inputs["api.key"])
+}
+
 // TestDataContract_MissingInput tests error handling for missing inputs.
 func TestDataContract_MissingInput(t *testing.T) {
 	dataContractMockNode := newDataContractMockNode(
@@ -552,3 +886,217 @@ func TestDataContract_ExecutionResults(t *testing.T) {
 	assert.Equal(t, "value1", frame2.GetInputs()["step1.output"])
 	assert.Equal(t, map[string]interface{}{"output": "value2"}, frame2.GetOutputs())
 }
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestFlowEngine_Execute_SharedHTTPClient(t *testing.T) {
+	var requested []string
+	transport := roundTripperFunc(
+		func(req *http.Request) (*http.Response, error) {
+			requested = append(requested, req.URL.Path)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(`{"id": "42"}`)),
+				Request:    req,
+			}, nil
+		},
+	)
+	flowInstance := flow.Flow{
+		Name: "Stubbed Flow",
+		Nodes: []node.AnyNode{
+			&node.RequestNode{
+				BaseNode: node.BaseNode{ID: "first", NodeType: node.TypeRequest},
+				Data:     node.RequestData{Method: http.MethodGet, URL: "http://api.example.invalid/first"},
+			},
+			&node.RequestNode{
+				BaseNode: node.BaseNode{ID: "second", NodeType: node.TypeRequest},
+				Data:     node.RequestData{Method: http.MethodGet, URL: "http://api.example.invalid/second"},
+			},
+		},
+		Edges:   []edge.Edge{{ID: "e1", Source: "first", Target: "second", Type: edge.TypeSuccess}},
+		Version: "1.0",
+	}
+
+	flowEngine, err := engine.NewFlowEngine(
+		flowInstance, &engine.Options{HTTPClient: httpclient.Config{Transport: transport}},
+	)
+	require.NoError(t, err)
+
+	result, err := flowEngine.Execute(make(map[string]interface{}))
+
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	assert.Equal(t, []string{"/first", "/second"}, requested)
+}
+
+func TestNewFlowEngine_DuplicateNodeID(t *testing.T) {
+	flowInstance := flow.Flow{
+		Name: "Flow",
+		Nodes: []node.AnyNode{
+			&MockNode{id: "node1", nodeType: node.TypeRequest, shouldPass: true},
+			&MockNode{id: "node1", nodeType: node.TypeRequest, shouldPass: true},
+		},
+		Version: "1.0",
+	}
+
+	flowEngine, err := engine.NewFlowEngine(flowInstance, &engine.Options{})
+
+	assert.Nil(t, flowEngine)
+	var validationErr *engine.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	require.Len(t, validationErr.Diagnostics, 1)
+	assert.Equal(t, flow.CodeDuplicateNodeID, validationErr.Diagnostics[0].Code)
+	assert.Equal(t, "invalid flow: node ID 'node1' is used more than once", err.Error())
+}
+
+func TestNewFlowEngine_InvalidHTTPClientConfig(t *testing.T) {
+	flowInstance := flow.Flow{
+		Name:    "Flow",
+		Nodes:   []node.AnyNode{&MockNode{id: "node1", nodeType: node.TypeRequest, shouldPass: true}},
+		Version: "1.0",
+	}
+
+	_, err := engine.NewFlowEngine(
+		flowInstance, &engine.Options{HTTPClient: httpclient.Config{ProxyURL: "://proxy"}},
+	)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid HTTP client configuration")
+}
+
+func TestFlowEngine_Execute_MasksSecrets(t *testing.T) {
+	var received []http.Header
+	transport := roundTripperFunc(
+		func(req *http.Request) (*http.Response, error) {
+			received = append(received, req.Header.Clone())
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(`{"session": "session-xyz", "user": "alice"}`)),
+				Request:    req,
+			}, nil
+		},
+	)
+	flowInstance, err := flow.ParseFromJSON(
+		[]byte(`{
+		"name": "Secret Flow",
+		"version": "1.0",
+		"secretInputs": ["credentials.apiKey"],
+		"nodes": [
+			{
+				"id": "login",
+				"type": "request",
+				"data": {
+					"method": "POST",
+					"url": "http://api.example.invalid/login",
+					"headers": {"X-Api-Key": "{{credentials.apiKey}}"}
+				},
+				"outputs": [
+					{"name": "session", "extractor": {"type": "jsonPath", "path": "$.session"}, "secret": true},
+					{"name": "user", "extractor": {"type": "jsonPath", "path": "$.user"}}
+				]
+			},
+			{
+				"id": "profile",
+				"type": "request",
+				"data": {
+					"method": "GET",
+					"url": "http://api.example.invalid/profile",
+					"auth": {"type": "bearer", "token": "{{login.session}}"}
+				}
+			}
+		],
+		"edges": [{"id": "e1", "source": "login", "target": "profile", "type": "success"}]
+	}`),
+	)
+	require.NoError(t, err)
+	var logs bytes.Buffer
+	flowEngine, err := engine.NewFlowEngine(
+		*flowInstance, &engine.Options{HTTPClient: httpclient.Config{Transport: transport}, LogOutput: &logs},
+	)
+	require.NoError(t, err)
+
+	result, err := flowEngine.Execute(
+		map[string]interface{}{"credentials": map[string]interface{}{"apiKey": "key-123"}},
+	)
+
+	require.NoError(t, err)
+	require.Len(t, received, 2)
+	assert.Equal(t, "key-123", received[0].Get("X-Api-Key"), "requests should receive the real values")
+	assert.Equal(t, "Bearer session-xyz", received[1].Get("Authorization"))
+
+	serialized, err := json.Marshal(result)
+	require.NoError(t, err)
+	for _, output := range []string{string(serialized), logs.String()} {
+		assert.NotContains(t, output, "key-123")
+		assert.NotContains(t, output, "session-xyz")
+	}
+	assert.Contains(t, logs.String(), redact.Mask)
+
+	login := node.MustAsRequestExecutionResult(result.ExecutionResults["login"])
+	assert.Equal(t, redact.Mask, login.RequestHeaders["X-Api-Key"])
+	assert.Equal(t, redact.Mask, login.Outputs["session"])
+	assert.Equal(t, "alice", login.Outputs["user"], "non-secret outputs should be kept")
+	profile := node.MustAsRequestExecutionResult(result.ExecutionResults["profile"])
+	assert.Equal(t, redact.Mask, profile.RequestHeaders["Authorization"])
+}
+
+func TestFlowEngine_Execute_MasksShortSecretsInGlobalLoggerOutput(t *testing.T) {
+	var logs bytes.Buffer
+	previous := log.Logger
+	//nolint:reassign // the test captures the output configured on the global logger
+	log.Logger = zerolog.New(&logs)
+	t.Cleanup(
+		func() {
+			//nolint:reassign // restores the global logger
+			log.Logger = previous
+		},
+	)
+	transport := roundTripperFunc(
+		func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(`{}`)),
+				Request:    req,
+			}, nil
+		},
+	)
+	flowInstance, err := flow.ParseFromJSON(
+		[]byte(`{
+		"name": "Short Secret Flow",
+		"version": "1.0",
+		"secretInputs": ["apiKey"],
+		"nodes": [
+			{
+				"id": "fetch",
+				"type": "request",
+				"data": {
+					"method": "GET",
+					"url": "http://api.example.invalid/items",
+					"headers": {"X-Api-Key": "{{apiKey}}"}
+				}
+			}
+		],
+		"edges": []
+	}`),
+	)
+	require.NoError(t, err)
+	flowEngine, err := engine.NewFlowEngine(
+		*flowInstance, &engine.Options{HTTPClient: httpclient.Config{Transport: transport}},
+	)
+	require.NoError(t, err)
+
+	result, err := flowEngine.Execute(map[string]interface{}{"apiKey": "k9x2"})
+
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	require.NotEmpty(t, logs.String(), "runs should log to the output of the global logger")
+	assert.NotContains(t, logs.String(), `"k9x2"`)
+	assert.Contains(t, logs.String(), `"apiKey":"***"`)
+}
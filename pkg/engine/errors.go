@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/flow"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/node"
+)
+
+// CycleError is returned by NewFlowEngine when the edges of a flow form a cycle.
+type CycleError struct {
+	// Path lists the node IDs along the cycle; the first and last elements are the same node.
+	Path []string
+}
+
+func (e *CycleError) Error() string {
+	return "cycle detected: " + strings.Join(e.Path, " -> ")
+}
+
+// ValidationError is returned by NewFlowEngine when flow.Validate reports errors for the flow.
+type ValidationError struct {
+	// Diagnostics lists every problem found, warnings included.
+	Diagnostics []flow.Diagnostic
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Diagnostics))
+	for _, diagnostic := range e.Diagnostics {
+		if diagnostic.Severity == flow.SeverityError {
+			messages = append(messages, diagnostic.Message)
+		}
+	}
+	return "invalid flow: " + strings.Join(messages, "; ")
+}
+
+// UnreachableNode is a node that could not be scheduled, together with the direct upstream nodes
+// whose edges were never resolved.
+type UnreachableNode struct {
+	NodeID    string   `json:"node_id"`
+	BlockedBy []string `json:"blocked_by,omitempty"`
+}
+
+// UnreachableNodesError lists the nodes that can never be scheduled because an upstream node
+// failed, was cancelled or was itself never started. It is joined to the error of an aborted run.
+type UnreachableNodesError struct {
+	Nodes []UnreachableNode
+}
+
+func (e *UnreachableNodesError) Error() string {
+	descriptions := make([]string, 0, len(e.Nodes))
+	for _, n := range e.Nodes {
+		descriptions = append(
+			descriptions, fmt.Sprintf("%s (blocked by %s)", n.NodeID, strings.Join(n.BlockedBy, ", ")),
+		)
+	}
+	return fmt.Sprintf(
+		"%d node(s) can never be scheduled: %s", len(e.Nodes), strings.Join(descriptions, "; "),
+	)
+}
+
+// UnresolvedOutputsError is returned when outputs declared by the flow reference variables that
+// the run did not produce, for instance outputs of a skipped node.
+type UnresolvedOutputsError struct {
+	References []node.UnresolvedReference
+}
+
+func (e *UnresolvedOutputsError) Error() string {
+	references := make([]string, 0, len(e.References))
+	for _, ref := range e.References {
+		references = append(references, fmt.Sprintf("%s (%s)", ref.Variable, ref.Location))
+	}
+	return fmt.Sprintf(
+		"%d unresolved template variable(s) in flow outputs: %s", len(e.References), strings.Join(references, ", "),
+	)
+}
@@ -1,30 +1,65 @@
 package engine
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"time"
 
-	"github.com/rs/zerolog/log"
-
 	"github.com/nanostack-dev/echopoint-flow-engine/pkg/node"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/redact"
 )
 
 type executionState struct {
+	ctx             context.Context
+	cancel          context.CancelFunc
 	allOutputs      map[string]map[string]interface{}
 	remainingInputs map[node.AnyNode]int
+	activeInputs    map[node.AnyNode]int
+	halted          map[node.AnyNode]bool
+	ready           []node.AnyNode
+	running         int
+	completions     chan nodeCompletion
+	scope           *runScope
+	failure         error
+	failureCode     node.ErrorCode
 	executedCount   int
 	result          *node.FlowExecutionResult
 	startTime       time.Time
 }
 
+// nodeCompletion is sent by a worker goroutine once a node has finished executing.
+type nodeCompletion struct {
+	node   node.AnyNode
+	result node.AnyExecutionResult
+	err    error
+}
+
+// executeNodes schedules the flow graph. All nodes whose incoming edges are resolved run
+// concurrently (bounded by MaxConcurrency). Only this coordinating goroutine mutates the
+// execution state; workers receive a snapshot of the outputs and report back on a channel.
 func (engine *FlowEngine) executeNodes(
+	ctx context.Context,
 	initialInputs map[string]interface{},
 	result *node.FlowExecutionResult,
+	scope *runScope,
 	startTime time.Time,
 ) error {
+	// An unhandled node failure cancels the run so that in-flight siblings are interrupted
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	state := &executionState{
+		ctx:             runCtx,
+		cancel:          cancel,
 		allOutputs:      make(map[string]map[string]interface{}),
 		remainingInputs: make(map[node.AnyNode]int),
+		activeInputs:    make(map[node.AnyNode]int),
+		halted:          make(map[node.AnyNode]bool),
+		completions:     make(chan nodeCompletion),
+		scope:           scope,
 		executedCount:   0,
 		result:          result,
 		startTime:       startTime,
@@ -32,99 +67,272 @@ func (engine *FlowEngine) executeNodes(
 
 	state.allOutputs[""] = initialInputs
 
-	log.Debug().
+	state.scope.logger.Debug().
 		Str("flowName", engine.flow.Name).
 		Any("initialInputs", initialInputs).
+		Int("maxConcurrency", engine.maxConcurrency).
 		Msg("Initialized flow execution with initial inputs")
 
-	for k, v := range engine.nodeEdgeInput {
-		state.remainingInputs[k] = v
+	for _, n := range engine.flow.Nodes {
+		state.remainingInputs[n] = engine.nodeEdgeInput[n]
+		if engine.nodeEdgeInput[n] == 0 {
+			engine.enqueueReady(n, state)
+		}
 	}
 
 	for {
-		next := engine.findNodeWithoutInput(state.remainingInputs)
+		engine.dispatchReadyNodes(state)
 
-		if next == nil {
-			return engine.finalizeExecution(state)
+		if state.running == 0 {
+			break
 		}
 
-		if err := engine.runNode(next, state); err != nil {
-			state.result.Error = err
-			state.result.DurationMS = time.Since(state.startTime).Milliseconds()
-			return err
+		engine.awaitCompletion(state)
+	}
+
+	if state.failure == nil && state.ctx.Err() != nil {
+		state.failure = fmt.Errorf("flow execution cancelled: %w", context.Cause(state.ctx))
+		state.failureCode = cancellationCode(context.Cause(state.ctx))
+	}
+
+	// Nodes finishing concurrently resolve in arbitrary order; report them in declaration order
+	engine.sortByDeclaration(state.result.SkippedNodes)
+	engine.sortByDeclaration(state.result.CancelledNodes)
+
+	if state.failure != nil {
+		engine.recordNotStartedNodes(state)
+		if unreachable := engine.unreachableNodes(state); unreachable != nil {
+			state.failure = fmt.Errorf("%w; %w", state.failure, unreachable)
 		}
+		failRun(state.result, state.failure, state.failureCode, state.startTime)
+		return state.failure
+	}
 
-		state.executedCount++
-		engine.propagateNodeOutputs(next, state)
-		engine.markNodeComplete(next, state)
+	return engine.finalizeExecution(state)
+}
+
+// awaitCompletion blocks until a running node finishes or the run is cancelled.
+func (engine *FlowEngine) awaitCompletion(state *executionState) {
+	if state.failure != nil {
+		// Already aborting: only wait for in-flight nodes to wind down
+		completion := <-state.completions
+		state.running--
+		engine.completeNode(completion, state)
+		return
+	}
+
+	select {
+	case completion := <-state.completions:
+		state.running--
+		engine.completeNode(completion, state)
+	case <-state.ctx.Done():
+		state.failure = fmt.Errorf("flow execution cancelled: %w", context.Cause(state.ctx))
+		state.failureCode = cancellationCode(context.Cause(state.ctx))
+		state.scope.logger.Warn().
+			Str("flowName", engine.flow.Name).
+			Int("runningNodes", state.running).
+			Err(state.failure).
+			Msg("Flow execution cancelled, waiting for in-flight nodes to stop")
 	}
 }
 
-func (engine *FlowEngine) runNode(n node.AnyNode, state *executionState) error {
+// dispatchReadyNodes starts ready nodes until the concurrency limit is reached.
+// No new nodes are started once an unhandled failure has occurred.
+func (engine *FlowEngine) dispatchReadyNodes(state *executionState) {
+	for len(state.ready) > 0 && state.failure == nil {
+		if engine.maxConcurrency > 0 && state.running >= engine.maxConcurrency {
+			return
+		}
+		if state.ctx.Err() != nil {
+			return
+		}
+
+		next := state.ready[0]
+		state.ready = state.ready[1:]
+
+		ctx, err := engine.prepareNode(next, state)
+		if err != nil {
+			engine.completeNode(nodeCompletion{node: next, err: err}, state)
+			continue
+		}
+
+		state.running++
+		go func() {
+			result, execErr := engine.runNode(next, ctx)
+			state.completions <- nodeCompletion{node: next, result: result, err: execErr}
+		}()
+	}
+}
+
+// prepareNode validates and assembles the inputs of a node before it is started.
+func (engine *FlowEngine) prepareNode(n node.AnyNode, state *executionState) (node.ExecutionContext, error) {
 	nodeID := n.GetID()
 	nodeType := n.GetType()
 
-	log.Debug().
+	state.scope.logger.Debug().
 		Str("flowName", engine.flow.Name).
 		Str("nodeID", nodeID).
 		Str("nodeType", string(nodeType)).
 		Msg("Preparing node execution")
 
-	if err := engine.validateInputs(n, state.allOutputs); err != nil {
-		log.Error().
+	if err := engine.validateInputs(n, state); err != nil {
+		state.scope.logger.Error().
 			Str("flowName", engine.flow.Name).
 			Str("nodeID", nodeID).
 			Str("nodeType", string(nodeType)).
 			Err(err).
 			Int64("durationMS", time.Since(state.startTime).Milliseconds()).
 			Msg("Node execution failed: input validation error")
-		return err
+		return node.ExecutionContext{}, err
 	}
 
 	inputs := engine.assembleInputs(n, state.allOutputs)
 
-	log.Debug().
+	state.scope.logger.Debug().
 		Str("flowName", engine.flow.Name).
 		Str("nodeID", nodeID).
 		Str("nodeType", string(nodeType)).
 		Any("inputs", inputs).
 		Msg("Assembled inputs for node")
 
-	if engine.beforeExecution != nil {
-		engine.beforeExecution(n)
+	// Nodes run concurrently, so each one gets its own snapshot of the outputs map
+	allOutputs := make(map[string]map[string]interface{}, len(state.allOutputs))
+	for sourceID, outputs := range state.allOutputs {
+		allOutputs[sourceID] = outputs
 	}
 
 	ctx := node.ExecutionContext{
-		Inputs:     inputs,
-		AllOutputs: state.allOutputs,
+		Context:          state.ctx,
+		Inputs:           inputs,
+		AllOutputs:       allOutputs,
+		LenientTemplates: !engine.flow.Settings.TemplatesStrict(),
+		HTTPClient:       engine.httpClient,
+		TokenCache:       state.scope.tokenCache,
+		Redactor:         state.scope.redactor,
+		Logger:           state.scope.logger,
+	}
+	if subflow, isContainer := engine.subflows[n]; isContainer {
+		ctx.RunSubflow = func(runCtx context.Context, subflowInputs map[string]interface{}) (
+			*node.FlowExecutionResult, error,
+		) {
+			return subflow.run(runCtx, subflowInputs, state.scope)
+		}
+	}
+	return ctx, nil
+}
+
+// runNode executes a node on a worker goroutine. Callbacks are serialized so user code
+// never has to deal with concurrent invocations.
+func (engine *FlowEngine) runNode(n node.AnyNode, ctx node.ExecutionContext) (node.AnyExecutionResult, error) {
+	if engine.beforeExecution != nil {
+		engine.callbackMutex.Lock()
+		engine.beforeExecution(n)
+		engine.callbackMutex.Unlock()
 	}
 
 	result, err := n.Execute(ctx)
 
-	state.result.ExecutionResults[n.GetID()] = result
+	// Callback with polymorphic result
+	if engine.afterExecution != nil {
+		engine.callbackMutex.Lock()
+		engine.afterExecution(n, result)
+		engine.callbackMutex.Unlock()
+	}
+
+	return result, err
+}
+
+// completeNode records the outcome of a node and resolves its outgoing edges.
+func (engine *FlowEngine) completeNode(completion nodeCompletion, state *executionState) {
+	n := completion.node
+	nodeID := n.GetID()
+	nodeType := n.GetType()
 
-	if err != nil {
-		log.Error().
+	if completion.result != nil {
+		state.result.ExecutionResults[nodeID] = completion.result
+	}
+
+	if completion.err != nil && state.ctx.Err() != nil &&
+		(errors.Is(completion.err, context.Canceled) || errors.Is(completion.err, context.DeadlineExceeded)) {
+		state.scope.logger.Warn().
 			Str("flowName", engine.flow.Name).
 			Str("nodeID", nodeID).
 			Str("nodeType", string(nodeType)).
-			Err(err).
-			Msg("Node execution failed")
-	} else {
-		log.Info().
+			Err(completion.err).
+			Msg("Node execution cancelled")
+		state.result.CancelledNodes = append(state.result.CancelledNodes, nodeID)
+		state.result.NodeStatuses[nodeID] = node.NodeStatusCancelled
+		state.halted[n] = true
+		delete(state.remainingInputs, n)
+		return
+	}
+
+	if completion.err == nil {
+		state.result.NodeStatuses[nodeID] = node.NodeStatusSucceeded
+		engine.registerSecretOutputs(n, completion.result, state.scope.redactor)
+		state.scope.logger.Info().
 			Str("flowName", engine.flow.Name).
 			Str("nodeID", nodeID).
 			Str("nodeType", string(nodeType)).
-			Any("outputs", result.GetOutputs()).
+			Any("outputs", completion.result.GetOutputs()).
 			Msg("Node executed successfully")
+
+		state.executedCount++
+		engine.propagateNodeOutputs(n, state)
+		engine.markNodeComplete(n, true, state)
+		return
 	}
 
-	// Callback with polymorphic result
-	if engine.afterExecution != nil {
-		engine.afterExecution(n, result)
+	state.scope.logger.Error().
+		Str("flowName", engine.flow.Name).
+		Str("nodeID", nodeID).
+		Str("nodeType", string(nodeType)).
+		Err(completion.err).
+		Msg("Node execution failed")
+
+	state.result.NodeStatuses[nodeID] = node.NodeStatusFailed
+	if !engine.hasFailureEdges(n) {
+		state.halted[n] = true
+		delete(state.remainingInputs, n)
+		if state.failure == nil {
+			state.failure = completion.err
+			state.failureCode = node.ErrorCodeNodeFailed
+			state.cancel()
+		}
+		return
 	}
 
-	return err
+	state.scope.logger.Warn().
+		Str("flowName", engine.flow.Name).
+		Str("nodeID", nodeID).
+		Err(completion.err).
+		Msg("Node failed, routing execution along failure edges")
+
+	state.executedCount++
+	engine.markNodeComplete(n, false, state)
+}
+
+// registerSecretOutputs makes the redactor mask the values of the outputs a node declares secret.
+func (engine *FlowEngine) registerSecretOutputs(
+	n node.AnyNode, result node.AnyExecutionResult, redactor *redact.Redactor,
+) {
+	outputs := result.GetOutputs()
+	for _, output := range n.GetOutputs() {
+		if output.Secret {
+			redactor.Add(outputs[output.Name])
+		}
+	}
+}
+
+// hasFailureEdges reports whether a node declares at least one failure edge,
+// meaning its failure is handled by the flow instead of aborting execution.
+func (engine *FlowEngine) hasFailureEdges(n node.AnyNode) bool {
+	for _, outgoing := range engine.nodeEdgeOutput[n] {
+		if outgoing.edge.IsFailure() {
+			return true
+		}
+	}
+	return false
 }
 
 func (engine *FlowEngine) propagateNodeOutputs(n node.AnyNode, state *executionState) {
@@ -135,12 +343,14 @@ func (engine *FlowEngine) propagateNodeOutputs(n node.AnyNode, state *executionS
 
 	state.allOutputs[nodeID] = outputs
 
-	for key, value := range outputs {
-		flatKey := fmt.Sprintf("%s.%s", nodeID, key)
-		state.result.FinalOutputs[flatKey] = value
+	if state.result.FinalOutputs != nil {
+		for key, value := range outputs {
+			flatKey := fmt.Sprintf("%s.%s", nodeID, key)
+			state.result.FinalOutputs[flatKey] = value
+		}
 	}
 
-	log.Debug().
+	state.scope.logger.Debug().
 		Str("flowName", engine.flow.Name).
 		Str("nodeID", nodeID).
 		Str("nodeType", string(nodeType)).
@@ -148,36 +358,177 @@ func (engine *FlowEngine) propagateNodeOutputs(n node.AnyNode, state *executionS
 		Msg("Node outputs stored")
 }
 
-func (engine *FlowEngine) markNodeComplete(n node.AnyNode, state *executionState) {
-	successors := engine.nodeEdgeOutput[n]
-	for _, successor := range successors {
-		state.remainingInputs[successor]--
+// markNodeComplete resolves the outgoing edges of a finished node. Edges matching the node's outcome
+// activate their target.
+func (engine *FlowEngine) markNodeComplete(n node.AnyNode, succeeded bool, state *executionState) {
+	delete(state.remainingInputs, n)
+
+	selector, _ := state.result.ExecutionResults[n.GetID()].(node.BranchSelector)
+	for _, outgoing := range engine.nodeEdgeOutput[n] {
+		taken := outgoing.edge.IsTraversable(succeeded)
+		// Edges naming a branch follow the branches selected by their source node
+		if taken && outgoing.edge.Branch != "" && !outgoing.edge.IsFailure() {
+			taken = selector != nil && selector.IsBranchSelected(outgoing.edge.Branch)
+		}
+		engine.resolveEdge(outgoing.target, taken, state)
+	}
+}
+
+// resolveEdge records one resolved incoming edge of a target node. Once all incoming edges are
+// resolved, the target becomes ready if at least one of them was taken, and is skipped otherwise.
+func (engine *FlowEngine) resolveEdge(target node.AnyNode, taken bool, state *executionState) {
+	state.remainingInputs[target]--
+	if taken {
+		state.activeInputs[target]++
+	}
+
+	if state.remainingInputs[target] > 0 {
+		return
+	}
+	if state.activeInputs[target] > 0 {
+		engine.enqueueReady(target, state)
+		return
 	}
+	engine.skipNode(target, state)
+}
+
+// skipNode marks a node as skipped and resolves its outgoing edges as not taken,
+// so that its exclusive subtree is skipped as well.
+func (engine *FlowEngine) skipNode(n node.AnyNode, state *executionState) {
+	state.result.SkippedNodes = append(state.result.SkippedNodes, n.GetID())
+	state.result.NodeStatuses[n.GetID()] = node.NodeStatusSkipped
+
+	state.scope.logger.Info().
+		Str("flowName", engine.flow.Name).
+		Str("nodeID", n.GetID()).
+		Str("nodeType", string(n.GetType())).
+		Msg("Node skipped: no incoming edge was taken")
+
 	delete(state.remainingInputs, n)
+
+	for _, outgoing := range engine.nodeEdgeOutput[n] {
+		engine.resolveEdge(outgoing.target, false, state)
+	}
+}
+
+// recordNotStartedNodes lists, in declaration order, the nodes that never started
+// because the run was aborted.
+func (engine *FlowEngine) recordNotStartedNodes(state *executionState) {
+	for _, n := range engine.flow.Nodes {
+		if _, pending := state.remainingInputs[n]; pending {
+			state.result.NotStartedNodes = append(state.result.NotStartedNodes, n.GetID())
+			state.result.NodeStatuses[n.GetID()] = node.NodeStatusNotStarted
+		}
+	}
+}
+
+// unreachableNodes describes the nodes that are still waiting for an incoming edge that will never
+// be resolved, because its source halted without resolving its edges or never started itself.
+// It returns nil when there are none.
+func (engine *FlowEngine) unreachableNodes(state *executionState) error {
+	upstream := make(map[node.AnyNode][]string)
+	for _, source := range engine.flow.Nodes {
+		_, pending := state.remainingInputs[source]
+		if !pending && !state.halted[source] {
+			continue
+		}
+		for _, outgoing := range engine.nodeEdgeOutput[source] {
+			if !slices.Contains(upstream[outgoing.target], source.GetID()) {
+				upstream[outgoing.target] = append(upstream[outgoing.target], source.GetID())
+			}
+		}
+	}
+
+	var unreachable []UnreachableNode
+	for _, n := range engine.flow.Nodes {
+		if _, pending := state.remainingInputs[n]; pending && len(upstream[n]) > 0 {
+			unreachable = append(unreachable, UnreachableNode{NodeID: n.GetID(), BlockedBy: upstream[n]})
+		}
+	}
+	if len(unreachable) == 0 {
+		return nil
+	}
+	return &UnreachableNodesError{Nodes: unreachable}
 }
 
 func (engine *FlowEngine) finalizeExecution(state *executionState) error {
+	// Cycles are rejected by NewFlowEngine, so this only guards against scheduling bugs
 	if len(state.remainingInputs) > 0 {
-		state.result.Error = fmt.Errorf(
-			"cycle detected or unreachable nodes: %d nodes not executed",
-			len(state.remainingInputs),
-		)
-		state.result.DurationMS = time.Since(state.startTime).Milliseconds()
-		log.Error().
+		err := engine.unreachableNodes(state)
+		if err == nil {
+			err = fmt.Errorf("%d nodes not executed", len(state.remainingInputs))
+		}
+		engine.recordNotStartedNodes(state)
+		failRun(state.result, err, node.ErrorCodeUnreachableNodes, state.startTime)
+		state.scope.logger.Error().
 			Str("flowName", engine.flow.Name).
 			Int("unreachableNodeCount", len(state.remainingInputs)).
 			Err(state.result.Error).
 			Int64("durationMS", state.result.DurationMS).
-			Msg("Flow execution failed: cycle or unreachable nodes detected")
+			Msg("Flow execution failed: unreachable nodes detected")
 		return state.result.Error
 	}
 
+	if len(engine.flow.Outputs) > 0 {
+		outputs, err := engine.resolveFlowOutputs(state)
+		if err != nil {
+			code := node.ErrorCodeOutputFailed
+			var unresolvedErr *UnresolvedOutputsError
+			if errors.As(err, &unresolvedErr) {
+				code = node.ErrorCodeUnresolvedTemplate
+			}
+			failRun(state.result, err, code, state.startTime)
+			state.scope.logger.Error().
+				Str("flowName", engine.flow.Name).
+				Err(err).
+				Int64("durationMS", state.result.DurationMS).
+				Msg("Flow execution failed: invalid flow outputs")
+			return err
+		}
+		state.result.Outputs = outputs
+	}
+
 	state.result.Success = true
 	state.result.DurationMS = time.Since(state.startTime).Milliseconds()
-	log.Info().
+	state.scope.logger.Info().
 		Str("flowName", engine.flow.Name).
 		Int("executedNodes", state.executedCount).
+		Int("skippedNodes", len(state.result.SkippedNodes)).
 		Int64("durationMS", state.result.DurationMS).
 		Msg("Flow execution completed successfully")
 	return nil
 }
+
+// resolveFlowOutputs evaluates the outputs declared by the flow against the inputs and node outputs
+// of the run. Outputs referencing variables the run did not produce fail the flow unless templates
+// are lenient; default() supplies a value for outputs of nodes that may be skipped.
+func (engine *FlowEngine) resolveFlowOutputs(state *executionState) (map[string]interface{}, error) {
+	resolver := node.NewTemplateResolver(state.allOutputs[""]).WithAllOutputs(state.allOutputs)
+	outputs := make(map[string]interface{}, len(engine.flow.Outputs))
+	for _, name := range slices.Sorted(maps.Keys(engine.flow.Outputs)) {
+		value, err := resolver.ResolveAt(engine.flow.Outputs[name], "outputs."+name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve flow output '%s': %w", name, err)
+		}
+		outputs[name] = value
+	}
+	if refs := resolver.Unresolved(); len(refs) > 0 {
+		err := &UnresolvedOutputsError{References: refs}
+		if engine.flow.Settings.TemplatesStrict() {
+			return nil, err
+		}
+		state.scope.logger.Warn().
+			Str("flowName", engine.flow.Name).
+			Err(err).
+			Msg("Unresolved templates left in flow outputs")
+	}
+	return outputs, nil
+}
+
+// cancellationCode returns the error code of a run cancelled for the given cause.
+func cancellationCode(cause error) node.ErrorCode {
+	if errors.Is(cause, context.DeadlineExceeded) {
+		return node.ErrorCodeTimeout
+	}
+	return node.ErrorCodeCancelled
+}
@@ -0,0 +1,144 @@
+package engine_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/engine"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/flow"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/httpclient"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/node"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const forEachFlowJSON = `{
+	"name": "ForEach Flow",
+	"version": "1.0",
+	"nodes": [
+		{
+			"id": "list-users",
+			"type": "request",
+			"data": {"method": "GET", "url": "{{baseUrl}}/users"},
+			"outputs": [{"name": "ids", "extractor": {"type": "jsonPath", "path": "$.users[*].id"}}]
+		},
+		{
+			"id": "each-user",
+			"type": "forEach",
+			"data": {
+				"items": "{{list-users.ids}}",
+				"concurrency": 2,
+				"collect": "name",
+				"subflow": {
+					"nodes": [
+						{
+							"id": "get-user",
+							"type": "request",
+							"data": {"method": "GET", "url": "{{baseUrl}}/users/{{item}}?position={{index}}"},
+							"outputs": [{"name": "name", "extractor": {"type": "jsonPath", "path": "$.name"}}]
+						}
+					],
+					"outputs": {"name": "{{get-user.name}}"}
+				}
+			}
+		},
+		{
+			"id": "summary",
+			"type": "request",
+			"data": {"method": "POST", "url": "{{baseUrl}}/summary", "body": {"names": "{{each-user.results}}"}}
+		}
+	],
+	"edges": [
+		{"id": "e1", "source": "list-users", "target": "each-user", "type": "success"},
+		{"id": "e2", "source": "each-user", "target": "summary", "type": "success"}
+	]
+}`
+
+func TestFlowEngine_Execute_ForEach(t *testing.T) {
+	var mu sync.Mutex
+	var summary string
+	transport := roundTripperFunc(
+		func(req *http.Request) (*http.Response, error) {
+			body := `{}`
+			switch req.URL.Path {
+			case "/users":
+				body = `{"users": [{"id": "u1"}, {"id": "u2"}, {"id": "u3"}]}`
+			case "/summary":
+				content, _ := io.ReadAll(req.Body)
+				mu.Lock()
+				summary = string(content)
+				mu.Unlock()
+			default:
+				id := strings.TrimPrefix(req.URL.Path, "/users/")
+				body = `{"name": "user ` + id + ` at ` + req.URL.Query().Get("position") + `"}`
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Request:    req,
+			}, nil
+		},
+	)
+	flowInstance, err := flow.ParseFromJSON([]byte(forEachFlowJSON))
+	require.NoError(t, err)
+	flowEngine, err := engine.NewFlowEngine(
+		*flowInstance, &engine.Options{HTTPClient: httpclient.Config{Transport: transport}},
+	)
+	require.NoError(t, err)
+
+	result, err := flowEngine.Execute(map[string]interface{}{"baseUrl": "http://api.example.invalid"})
+
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	expectedNames := []interface{}{"user u1 at 0", "user u2 at 1", "user u3 at 2"}
+	assert.Equal(t, expectedNames, result.FinalOutputs["each-user.results"])
+	assert.Equal(t, 3, result.FinalOutputs["each-user.count"])
+	assert.JSONEq(t, `{"names": ["user u1 at 0", "user u2 at 1", "user u3 at 2"]}`, summary)
+
+	forEachResult := node.MustAsForEachExecutionResult(result.ExecutionResults["each-user"])
+	require.Len(t, forEachResult.Iterations, 3)
+	for i, iteration := range forEachResult.Iterations {
+		assert.Equal(t, i, iteration.Index)
+		getUser := node.MustAsRequestExecutionResult(iteration.Result.ExecutionResults["get-user"])
+		assert.Equal(t, expectedNames[i], getUser.Outputs["name"])
+	}
+}
+
+func TestNewFlowEngine_InvalidSubflow(t *testing.T) {
+	flowInstance, err := flow.ParseFromJSON(
+		[]byte(`{
+		"name": "Invalid Subflow",
+		"nodes": [
+			{
+				"id": "loop",
+				"type": "forEach",
+				"data": {
+					"items": [1, 2],
+					"subflow": {
+						"nodes": [
+							{"id": "a", "type": "delay", "data": {"duration": 1}},
+							{"id": "b", "type": "delay", "data": {"duration": 1}}
+						],
+						"edges": [
+							{"id": "e1", "source": "a", "target": "b", "type": "success"},
+							{"id": "e2", "source": "b", "target": "a", "type": "success"}
+						]
+					}
+				}
+			}
+		]
+	}`),
+	)
+	require.NoError(t, err)
+
+	_, err = engine.NewFlowEngine(*flowInstance, nil)
+
+	require.Error(t, err)
+	var cycleErr *engine.CycleError
+	require.ErrorAs(t, err, &cycleErr)
+	assert.Equal(t, "invalid subflow of node 'loop': cycle detected: a -> b -> a", err.Error())
+}
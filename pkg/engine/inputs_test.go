@@ -0,0 +1,91 @@
+package engine_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/engine"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/flow"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/httpclient"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/node"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const typedInputsFlowJSON = `{
+	"name": "Typed Inputs",
+	"version": "1.0",
+	"inputs": [
+		{"name": "baseUrl", "type": "string", "required": true},
+		{"name": "pageSize", "type": "integer", "default": 20},
+		{"name": "apiKey", "type": "string", "default": "default-key", "secret": true}
+	],
+	"nodes": [
+		{
+			"id": "list-items",
+			"type": "request",
+			"data": {
+				"method": "GET",
+				"url": "{{baseUrl}}/items",
+				"queryParams": {"size": "{{pageSize}}"},
+				"headers": {"X-Api-Key": "{{apiKey}}"}
+			}
+		}
+	]
+}`
+
+func TestFlowEngine_Execute_TypedInputs(t *testing.T) {
+	var query, apiKey string
+	transport := roundTripperFunc(
+		func(req *http.Request) (*http.Response, error) {
+			query = req.URL.RawQuery
+			apiKey = req.Header.Get("X-Api-Key")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(`{}`)),
+				Request:    req,
+			}, nil
+		},
+	)
+	flowInstance, err := flow.ParseFromJSON([]byte(typedInputsFlowJSON))
+	require.NoError(t, err)
+	require.False(t, flow.HasErrors(flow.Validate(*flowInstance)))
+	flowEngine, err := engine.NewFlowEngine(
+		*flowInstance, &engine.Options{HTTPClient: httpclient.Config{Transport: transport}},
+	)
+	require.NoError(t, err)
+
+	result, err := flowEngine.Execute(map[string]interface{}{"baseUrl": "http://api.example.invalid"})
+
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	assert.Equal(t, "size=20", query)
+	assert.Equal(t, "default-key", apiKey)
+	listItems := node.MustAsRequestExecutionResult(result.ExecutionResults["list-items"])
+	assert.Equal(t, "***", listItems.RequestHeaders["X-Api-Key"])
+}
+
+func TestFlowEngine_Execute_InvalidInputs(t *testing.T) {
+	flowInstance, err := flow.ParseFromJSON([]byte(typedInputsFlowJSON))
+	require.NoError(t, err)
+	flowEngine, err := engine.NewFlowEngine(*flowInstance, nil)
+	require.NoError(t, err)
+
+	result, err := flowEngine.Execute(map[string]interface{}{"pageSize": "many"})
+
+	require.Error(t, err)
+	var inputErr *flow.InputError
+	require.ErrorAs(t, err, &inputErr)
+	assert.Equal(
+		t, []flow.InputProblem{
+			{Input: "baseUrl", Message: "is required"},
+			{Input: "pageSize", Message: `expected integer, got "many"`},
+		}, inputErr.Problems,
+	)
+	assert.False(t, result.Success)
+	assert.Equal(t, node.ErrorCodeInvalidInput, *result.ErrorCode)
+	assert.Empty(t, result.ExecutionResults)
+}
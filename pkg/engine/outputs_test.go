@@ -0,0 +1,162 @@
+package engine_test
+
+import (
+	"testing"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/engine"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/flow"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/httpclient"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/node"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const declaredLoginFlowJSON = `{
+	"name": "Login",
+	"version": "3",
+	"nodes": [
+		{
+			"id": "login",
+			"type": "request",
+			"data": {"method": "POST", "url": "{{baseUrl}}/login", "body": {"username": "{{username}}"}},
+			"outputs": [{"name": "token", "extractor": {"type": "jsonPath", "path": "$.token"}}]
+		}
+	],
+	"outputs": {
+		"accessToken": "{{login.token}}",
+		"session": {"user": "{{username}}", "expiresIn": "{{default(login.expiresIn, 3600)}}"}
+	}
+}`
+
+func TestFlowEngine_Execute_DeclaredOutputs(t *testing.T) {
+	flowInstance, err := flow.ParseFromJSON([]byte(declaredLoginFlowJSON))
+	require.NoError(t, err)
+	require.False(t, flow.HasErrors(flow.Validate(*flowInstance)))
+	flowEngine, err := engine.NewFlowEngine(
+		*flowInstance, &engine.Options{HTTPClient: httpclient.Config{Transport: loginTransport()}},
+	)
+	require.NoError(t, err)
+
+	result, err := flowEngine.Execute(
+		map[string]interface{}{"baseUrl": "http://api.example.invalid", "username": "alice"},
+	)
+
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	assert.Equal(
+		t, map[string]interface{}{
+			"accessToken": "tok-123",
+			"session":     map[string]interface{}{"user": "alice", "expiresIn": 3600},
+		}, result.Outputs,
+	)
+	accessToken, exists := result.Output("accessToken")
+	assert.True(t, exists)
+	assert.Equal(t, "tok-123", accessToken)
+	_, exists = result.Output("login.token")
+	assert.False(t, exists, "node outputs should not be exposed")
+	assert.Nil(t, result.FinalOutputs)
+}
+
+func TestFlowEngine_Execute_SubflowDeclaredOutputs(t *testing.T) {
+	loginFlow, err := flow.ParseFromJSON([]byte(declaredLoginFlowJSON))
+	require.NoError(t, err)
+	flowInstance, err := flow.ParseFromJSON(
+		[]byte(`{
+		"name": "Session",
+		"nodes": [
+			{
+				"id": "authenticate",
+				"type": "subflow",
+				"data": {
+					"ref": {"name": "Login", "version": "3"},
+					"inputs": {"baseUrl": "{{baseUrl}}", "username": "{{user}}"},
+					"outputs": {"token": "accessToken"}
+				}
+			}
+		],
+		"outputs": {"authorization": "Bearer {{authenticate.token}}"}
+	}`),
+	)
+	require.NoError(t, err)
+	flowEngine, err := engine.NewFlowEngine(
+		*flowInstance, &engine.Options{
+			HTTPClient: httpclient.Config{Transport: loginTransport()},
+			Flows:      []flow.Flow{*loginFlow},
+		},
+	)
+	require.NoError(t, err)
+
+	result, err := flowEngine.Execute(map[string]interface{}{"baseUrl": "http://api.example.invalid", "user": "alice"})
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"authorization": "Bearer tok-123"}, result.Outputs)
+}
+
+func TestFlowEngine_Execute_UnresolvedOutputs(t *testing.T) {
+	tests := []struct {
+		name            string
+		settings        string
+		expectedErr     string
+		expectedOutputs map[string]interface{}
+	}{
+		{
+			name:        "strict templates",
+			settings:    `{}`,
+			expectedErr: "1 unresolved template variable(s) in flow outputs: retry.token (outputs.refresh)",
+		},
+		{
+			name:     "lenient templates",
+			settings: `{"strictTemplates": false}`,
+			expectedOutputs: map[string]interface{}{
+				"token": "tok-123", "refresh": "{{retry.token}}",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				flowInstance, err := flow.ParseFromJSON(
+					[]byte(`{
+					"name": "Login",
+					"settings": ` + tt.settings + `,
+					"nodes": [
+						{
+							"id": "login",
+							"type": "request",
+							"data": {"method": "POST", "url": "{{baseUrl}}/login"},
+							"outputs": [{"name": "token", "extractor": {"type": "jsonPath", "path": "$.token"}}]
+						},
+						{
+							"id": "retry",
+							"type": "request",
+							"data": {"method": "POST", "url": "{{baseUrl}}/login"},
+							"outputs": [{"name": "token", "extractor": {"type": "jsonPath", "path": "$.token"}}]
+						}
+					],
+					"edges": [{"id": "e1", "source": "login", "target": "retry", "type": "failure"}],
+					"outputs": {"token": "{{login.token}}", "refresh": "{{retry.token}}"}
+				}`),
+				)
+				require.NoError(t, err)
+				flowEngine, err := engine.NewFlowEngine(
+					*flowInstance, &engine.Options{HTTPClient: httpclient.Config{Transport: loginTransport()}},
+				)
+				require.NoError(t, err)
+
+				result, err := flowEngine.Execute(map[string]interface{}{"baseUrl": "http://api.example.invalid"})
+
+				if tt.expectedErr != "" {
+					require.Error(t, err)
+					assert.Equal(t, tt.expectedErr, err.Error())
+					assert.False(t, result.Success)
+					assert.Equal(t, node.ErrorCodeUnresolvedTemplate, *result.ErrorCode)
+					assert.Nil(t, result.Outputs)
+					return
+				}
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedOutputs, result.Outputs)
+			},
+		)
+	}
+}
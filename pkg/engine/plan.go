@@ -0,0 +1,108 @@
+package engine
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/node"
+)
+
+// ExecutionPlan is the order in which the engine starts the nodes of a flow.
+type ExecutionPlan struct {
+	Steps []PlanStep `json:"steps"`
+}
+
+// PlanStep describes one node of an ExecutionPlan.
+type PlanStep struct {
+	NodeID string `json:"node_id"`
+	// Stage is the length of the longest dependency chain leading to the node.
+	// Nodes of the same stage do not depend on each other and may run concurrently.
+	Stage     int      `json:"stage"`
+	Priority  int      `json:"priority"`
+	DependsOn []string `json:"depends_on,omitempty"`
+}
+
+// ExecutionPlan computes the scheduling order of the flow without running it: a topological order
+// in which, among nodes whose dependencies are satisfied, higher priorities come first and ties
+// keep the declaration order of Flow.Nodes. This is the order in which a run with MaxConcurrency 1
+// starts the nodes; nodes skipped by branching at run time are still listed. NewFlowEngine rejects
+// cyclic flows, so the plan always lists every node.
+func (engine *FlowEngine) ExecutionPlan() *ExecutionPlan {
+	remaining := make(map[node.AnyNode]int, len(engine.nodeEdgeInput))
+	stages := make(map[node.AnyNode]int, len(engine.nodeEdgeInput))
+	dependsOn := make(map[node.AnyNode][]string)
+	var ready []node.AnyNode
+
+	for _, n := range engine.flow.Nodes {
+		for _, outgoing := range engine.nodeEdgeOutput[n] {
+			dependsOn[outgoing.target] = append(dependsOn[outgoing.target], n.GetID())
+		}
+	}
+	for _, n := range engine.flow.Nodes {
+		remaining[n] = engine.nodeEdgeInput[n]
+		if remaining[n] == 0 {
+			ready = engine.insertByScheduling(ready, n)
+		}
+	}
+
+	plan := &ExecutionPlan{Steps: make([]PlanStep, 0, len(engine.flow.Nodes))}
+	for len(ready) > 0 {
+		n := ready[0]
+		ready = ready[1:]
+
+		plan.Steps = append(
+			plan.Steps, PlanStep{
+				NodeID:    n.GetID(),
+				Stage:     stages[n],
+				Priority:  priorityOf(n),
+				DependsOn: dependsOn[n],
+			},
+		)
+
+		for _, outgoing := range engine.nodeEdgeOutput[n] {
+			stages[outgoing.target] = max(stages[outgoing.target], stages[n]+1)
+			remaining[outgoing.target]--
+			if remaining[outgoing.target] == 0 {
+				ready = engine.insertByScheduling(ready, outgoing.target)
+			}
+		}
+	}
+
+	return plan
+}
+
+// priorityOf returns the scheduling priority of a node, zero if it does not declare one.
+func priorityOf(n node.AnyNode) int {
+	if prioritized, ok := n.(node.Prioritized); ok {
+		return prioritized.GetPriority()
+	}
+	return 0
+}
+
+// compareScheduling orders nodes by descending priority, then by declaration order.
+func (engine *FlowEngine) compareScheduling(a, b node.AnyNode) int {
+	return cmp.Or(
+		cmp.Compare(priorityOf(b), priorityOf(a)),
+		cmp.Compare(engine.nodeOrder[a], engine.nodeOrder[b]),
+	)
+}
+
+// insertByScheduling inserts n into the sorted ready queue.
+func (engine *FlowEngine) insertByScheduling(ready []node.AnyNode, n node.AnyNode) []node.AnyNode {
+	index, _ := slices.BinarySearchFunc(ready, n, engine.compareScheduling)
+	return slices.Insert(ready, index, n)
+}
+
+// enqueueReady adds a node whose incoming edges are resolved to the ready queue.
+func (engine *FlowEngine) enqueueReady(n node.AnyNode, state *executionState) {
+	state.ready = engine.insertByScheduling(state.ready, n)
+}
+
+// sortByDeclaration sorts node IDs by the declaration order of Flow.Nodes.
+func (engine *FlowEngine) sortByDeclaration(nodeIDs []string) {
+	slices.SortFunc(
+		nodeIDs, func(a, b string) int {
+			return cmp.Compare(engine.nodeOrder[engine.nodeMap[a]], engine.nodeOrder[engine.nodeMap[b]])
+		},
+	)
+}
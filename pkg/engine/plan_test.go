@@ -0,0 +1,142 @@
+package engine_test
+
+import (
+	"testing"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/edge"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/engine"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/flow"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/node"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// prioritizedNode is a MockNode with an explicit scheduling priority.
+type prioritizedNode struct {
+	MockNode
+
+	priority int
+}
+
+func (n *prioritizedNode) GetPriority() int {
+	return n.priority
+}
+
+func newPrioritizedNode(id string, priority int) *prioritizedNode {
+	return &prioritizedNode{
+		MockNode: MockNode{id: id, nodeType: node.TypeRequest, shouldPass: true},
+		priority: priority,
+	}
+}
+
+// schedulingFlow has two roots and a diamond: login fans out to profile, orders and audit,
+// which all feed into report.
+func schedulingFlow() flow.Flow {
+	return flow.Flow{
+		Name: "Scheduling Flow",
+		Nodes: []node.AnyNode{
+			newPrioritizedNode("health", 0),
+			newPrioritizedNode("login", 0),
+			newPrioritizedNode("profile", 0),
+			newPrioritizedNode("orders", 0),
+			newPrioritizedNode("audit", 5),
+			newPrioritizedNode("report", 0),
+		},
+		Edges: []edge.Edge{
+			{ID: "e1", Source: "login", Target: "profile", Type: edge.TypeSuccess},
+			{ID: "e2", Source: "login", Target: "orders", Type: edge.TypeSuccess},
+			{ID: "e3", Source: "login", Target: "audit", Type: edge.TypeSuccess},
+			{ID: "e4", Source: "profile", Target: "report", Type: edge.TypeSuccess},
+			{ID: "e5", Source: "orders", Target: "report", Type: edge.TypeSuccess},
+		},
+		Version: "1.0",
+	}
+}
+
+func TestFlowEngine_ExecutionPlan(t *testing.T) {
+	flowEngine, err := engine.NewFlowEngine(schedulingFlow(), &engine.Options{})
+	require.NoError(t, err)
+
+	plan := flowEngine.ExecutionPlan()
+
+	assert.Equal(
+		t, []engine.PlanStep{
+			{NodeID: "health", Stage: 0},
+			{NodeID: "login", Stage: 0},
+			{NodeID: "audit", Stage: 1, Priority: 5, DependsOn: []string{"login"}},
+			{NodeID: "profile", Stage: 1, DependsOn: []string{"login"}},
+			{NodeID: "orders", Stage: 1, DependsOn: []string{"login"}},
+			{NodeID: "report", Stage: 2, DependsOn: []string{"profile", "orders"}},
+		}, plan.Steps,
+	)
+}
+
+func TestFlowEngine_ExecutionPlan_Cycle(t *testing.T) {
+	flowInstance := flow.Flow{
+		Name: "Cyclic Flow",
+		Nodes: []node.AnyNode{
+			newPrioritizedNode("start", 0),
+			newPrioritizedNode("node1", 0),
+			newPrioritizedNode("node2", 0),
+		},
+		Edges: []edge.Edge{
+			{ID: "e1", Source: "node1", Target: "node2", Type: edge.TypeSuccess},
+			{ID: "e2", Source: "node2", Target: "node1", Type: edge.TypeSuccess},
+		},
+		Version: "1.0",
+	}
+
+	// A cyclic flow has no plan: the engine refuses it before any plan can be computed
+	flowEngine, err := engine.NewFlowEngine(flowInstance, &engine.Options{})
+
+	assert.Nil(t, flowEngine)
+	var cycleErr *engine.CycleError
+	require.ErrorAs(t, err, &cycleErr)
+	assert.Equal(t, []string{"node1", "node2", "node1"}, cycleErr.Path)
+}
+
+func TestFlowEngine_Execute_FollowsExecutionPlan(t *testing.T) {
+	for range 5 {
+		var started []string
+		flowEngine, err := engine.NewFlowEngine(
+			schedulingFlow(), &engine.Options{
+				MaxConcurrency: 1,
+				BeforeExecution: func(n node.AnyNode) {
+					started = append(started, n.GetID())
+				},
+			},
+		)
+		require.NoError(t, err)
+
+		result, err := flowEngine.Execute(make(map[string]interface{}))
+
+		require.NoError(t, err)
+		require.True(t, result.Success)
+		assert.Equal(t, []string{"health", "login", "audit", "profile", "orders", "report"}, started)
+	}
+}
+
+func TestFlowEngine_Execute_SkippedNodesInDeclarationOrder(t *testing.T) {
+	flowInstance := flow.Flow{
+		Name: "Skipped Flow",
+		Nodes: []node.AnyNode{
+			&MockNode{id: "check", nodeType: node.TypeRequest, shouldPass: true},
+			&MockNode{id: "alert", nodeType: node.TypeRequest, shouldPass: true},
+			&MockNode{id: "page", nodeType: node.TypeRequest, shouldPass: true},
+			&MockNode{id: "ticket", nodeType: node.TypeRequest, shouldPass: true},
+		},
+		Edges: []edge.Edge{
+			{ID: "e1", Source: "check", Target: "ticket", Type: edge.TypeFailure},
+			{ID: "e2", Source: "check", Target: "alert", Type: edge.TypeFailure},
+			{ID: "e3", Source: "alert", Target: "page", Type: edge.TypeSuccess},
+		},
+		Version: "1.0",
+	}
+	flowEngine, err := engine.NewFlowEngine(flowInstance, &engine.Options{})
+	require.NoError(t, err)
+
+	result, err := flowEngine.Execute(make(map[string]interface{}))
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alert", "page", "ticket"}, result.SkippedNodes)
+}
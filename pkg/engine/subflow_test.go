@@ -0,0 +1,252 @@
+package engine_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/engine"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/flow"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/httpclient"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/node"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const loginFlowJSON = `{
+	"name": "Login",
+	"version": "2",
+	"secretInputs": ["password"],
+	"nodes": [
+		{
+			"id": "login",
+			"type": "request",
+			"data": {
+				"method": "POST",
+				"url": "{{baseUrl}}/login",
+				"body": {"username": "{{username}}", "password": "{{password}}"}
+			},
+			"outputs": [{"name": "token", "extractor": {"type": "jsonPath", "path": "$.token"}}]
+		}
+	],
+	"outputs": {"token": "{{login.token}}"}
+}`
+
+const loginChildNodesJSON = `{
+	"nodes": [
+		{
+			"id": "login",
+			"type": "request",
+			"data": {"method": "POST", "url": "{{baseUrl}}/login", "body": {"username": "{{username}}"}},
+			"outputs": [{"name": "token", "extractor": {"type": "jsonPath", "path": "$.token"}}]
+		}
+	],
+	"outputs": {"token": "{{login.token}}"}
+}`
+
+func subflowParentJSON(child string) string {
+	return `{
+	"name": "Orders",
+	"version": "1.0",
+	"nodes": [
+		{
+			"id": "authenticate",
+			"type": "subflow",
+			"data": {
+				` + child + `,
+				"inputs": {"baseUrl": "{{baseUrl}}", "username": "{{user}}", "password": "{{password}}"},
+				"outputs": {"token": "token"}
+			}
+		},
+		{
+			"id": "list-orders",
+			"type": "request",
+			"data": {
+				"method": "GET",
+				"url": "{{baseUrl}}/orders",
+				"headers": {"Authorization": "Bearer {{authenticate.token}}"}
+			},
+			"outputs": [{"name": "count", "extractor": {"type": "jsonPath", "path": "$.count"}}]
+		}
+	],
+	"edges": [{"id": "e1", "source": "authenticate", "target": "list-orders", "type": "success"}]
+}`
+}
+
+func loginTransport() http.RoundTripper {
+	return roundTripperFunc(
+		func(req *http.Request) (*http.Response, error) {
+			body := `{"count": 0}`
+			switch {
+			case req.URL.Path == "/login":
+				body = `{"token": "tok-123"}`
+			case req.Header.Get("Authorization") == "Bearer tok-123":
+				body = `{"count": 4}`
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Request:    req,
+			}, nil
+		},
+	)
+}
+
+func TestFlowEngine_Execute_Subflow(t *testing.T) {
+	loginFlow, err := flow.ParseFromJSON([]byte(loginFlowJSON))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		child string
+	}{
+		{name: "referenced flow", child: `"ref": {"name": "Login", "version": "2"}`},
+		{name: "embedded flow", child: `"flow": ` + loginChildNodesJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				flowInstance, err := flow.ParseFromJSON([]byte(subflowParentJSON(tt.child)))
+				require.NoError(t, err)
+				require.False(t, flow.HasErrors(flow.Validate(*flowInstance)))
+				flowEngine, err := engine.NewFlowEngine(
+					*flowInstance, &engine.Options{
+						HTTPClient: httpclient.Config{Transport: loginTransport()},
+						Flows:      []flow.Flow{*loginFlow},
+					},
+				)
+				require.NoError(t, err)
+
+				result, err := flowEngine.Execute(
+					map[string]interface{}{"baseUrl": "http://api.example.invalid", "user": "alice", "password": "s3cret"},
+				)
+
+				require.NoError(t, err)
+				require.True(t, result.Success)
+				assert.Equal(t, "tok-123", result.FinalOutputs["authenticate.token"])
+				assert.InDelta(t, 4.0, result.FinalOutputs["list-orders.count"], 0)
+
+				subflowResult := node.MustAsSubflowExecutionResult(result.ExecutionResults["authenticate"])
+				require.NotNil(t, subflowResult.Result)
+				login := node.MustAsRequestExecutionResult(subflowResult.Result.ExecutionResults["login"])
+				assert.Equal(t, "alice", login.Inputs["username"])
+				assert.Equal(t, "tok-123", login.Outputs["token"])
+			},
+		)
+	}
+}
+
+func TestFlowEngine_Execute_SubflowMasksChildSecrets(t *testing.T) {
+	loginFlow, err := flow.ParseFromJSON([]byte(loginFlowJSON))
+	require.NoError(t, err)
+	flowInstance, err := flow.ParseFromJSON(
+		[]byte(subflowParentJSON(`"ref": {"name": "Login", "version": "2"}`)),
+	)
+	require.NoError(t, err)
+	flowEngine, err := engine.NewFlowEngine(
+		*flowInstance, &engine.Options{
+			HTTPClient: httpclient.Config{Transport: loginTransport()},
+			Flows:      []flow.Flow{*loginFlow},
+		},
+	)
+	require.NoError(t, err)
+
+	result, err := flowEngine.Execute(
+		map[string]interface{}{"baseUrl": "http://api.example.invalid", "user": "alice", "password": "s3cret"},
+	)
+
+	require.NoError(t, err)
+	subflowResult := node.MustAsSubflowExecutionResult(result.ExecutionResults["authenticate"])
+	assert.Equal(t, "***", subflowResult.Inputs["password"])
+	login := node.MustAsRequestExecutionResult(subflowResult.Result.ExecutionResults["login"])
+	assert.Equal(t, map[string]interface{}{"username": "alice", "password": "***"}, login.RequestBody)
+}
+
+func TestNewFlowEngine_InvalidFlowReference(t *testing.T) {
+	recursiveFlow, err := flow.ParseFromJSON(
+		[]byte(`{
+		"name": "Login",
+		"version": "2",
+		"nodes": [{"id": "again", "type": "subflow", "data": {"ref": {"name": "Login", "version": "2"}}}]
+	}`),
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		child       string
+		expectedErr string
+	}{
+		{
+			name:        "unknown flow",
+			child:       `"ref": {"name": "Login", "version": "3"}`,
+			expectedErr: "invalid subflow of node 'authenticate': flow 'Login' version '3' is not registered",
+		},
+		{
+			name:  "recursive flow",
+			child: `"ref": {"name": "Login", "version": "2"}`,
+			expectedErr: "invalid subflow of node 'authenticate': invalid subflow of node 'again': " +
+				"flow 'Login' version '2' runs itself",
+		},
+		{
+			name:        "no child flow",
+			child:       `"inputs": {}`,
+			expectedErr: "invalid subflow of node 'authenticate': the node must either reference or embed a flow",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				flowInstance, err := flow.ParseFromJSON([]byte(subflowParentJSON(tt.child)))
+				require.NoError(t, err)
+
+				_, err = engine.NewFlowEngine(*flowInstance, &engine.Options{Flows: []flow.Flow{*recursiveFlow}})
+
+				require.Error(t, err)
+				assert.Equal(t, tt.expectedErr, err.Error())
+			},
+		)
+	}
+}
+
+func TestFlowEngine_Execute_ResultJSONRoundTrip(t *testing.T) {
+	loginFlow, err := flow.ParseFromJSON([]byte(loginFlowJSON))
+	require.NoError(t, err)
+	flowInstance, err := flow.ParseFromJSON(
+		[]byte(subflowParentJSON(`"ref": {"name": "Login", "version": "2"}`)),
+	)
+	require.NoError(t, err)
+	flowEngine, err := engine.NewFlowEngine(
+		*flowInstance, &engine.Options{
+			HTTPClient: httpclient.Config{Transport: loginTransport()},
+			Flows:      []flow.Flow{*loginFlow},
+		},
+	)
+	require.NoError(t, err)
+	result, err := flowEngine.Execute(
+		map[string]interface{}{"baseUrl": "http://api.example.invalid", "user": "alice", "password": "s3cret"},
+	)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(result)
+	require.NoError(t, err)
+	var decoded node.FlowExecutionResult
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	redecoded, err := json.Marshal(&decoded)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(data), string(redecoded))
+	assert.Equal(
+		t, map[string]node.NodeStatus{
+			"authenticate": node.NodeStatusSucceeded, "list-orders": node.NodeStatusSucceeded,
+		}, decoded.NodeStatuses,
+	)
+	subflowResult := node.MustAsSubflowExecutionResult(decoded.ExecutionResults["authenticate"])
+	login := node.MustAsRequestExecutionResult(subflowResult.Result.ExecutionResults["login"])
+	assert.Equal(t, "tok-123", login.Outputs["token"])
+}
@@ -0,0 +1,87 @@
+package engine_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/engine"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/flow"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const transformFlowJSON = `{
+	"name": "Transform Flow",
+	"version": "1.0",
+	"nodes": [
+		{
+			"id": "list-orders",
+			"type": "request",
+			"data": {"method": "GET", "url": "{{baseUrl}}/orders"},
+			"outputs": [
+				{"name": "ids", "extractor": {"type": "jsonPath", "path": "$.orders[*].id"}},
+				{"name": "amounts", "extractor": {"type": "jsonPath", "path": "$.orders[*].amount"}}
+			]
+		},
+		{
+			"id": "summarize",
+			"type": "transform",
+			"data": {
+				"mapping": {
+					"body": {"orderIds": "{{join(list-orders.ids)}}", "total": "{{sum(list-orders.amounts)}}"}
+				}
+			}
+		},
+		{
+			"id": "create-invoice",
+			"type": "request",
+			"data": {"method": "POST", "url": "{{baseUrl}}/invoices", "body": "{{summarize.body}}"}
+		}
+	],
+	"edges": [
+		{"id": "e1", "source": "list-orders", "target": "summarize", "type": "success"},
+		{"id": "e2", "source": "summarize", "target": "create-invoice", "type": "success"}
+	]
+}`
+
+func TestFlowEngine_Execute_Transform(t *testing.T) {
+	var mu sync.Mutex
+	var invoice string
+	transport := roundTripperFunc(
+		func(req *http.Request) (*http.Response, error) {
+			body := `{}`
+			switch req.URL.Path {
+			case "/orders":
+				body = `{"orders": [{"id": "o1", "amount": 10}, {"id": "o2", "amount": 32.5}]}`
+			case "/invoices":
+				content, _ := io.ReadAll(req.Body)
+				mu.Lock()
+				invoice = string(content)
+				mu.Unlock()
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Request:    req,
+			}, nil
+		},
+	)
+	flowInstance, err := flow.ParseFromJSON([]byte(transformFlowJSON))
+	require.NoError(t, err)
+	require.False(t, flow.HasErrors(flow.Validate(*flowInstance)))
+	flowEngine, err := engine.NewFlowEngine(
+		*flowInstance, &engine.Options{HTTPClient: httpclient.Config{Transport: transport}},
+	)
+	require.NoError(t, err)
+
+	result, err := flowEngine.Execute(map[string]interface{}{"baseUrl": "http://api.example.invalid"})
+
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	assert.JSONEq(t, `{"orderIds": "o1,o2", "total": 42.5}`, invoice)
+}
@@ -27,7 +27,6 @@ func (e HeaderExtractor) Extract(ctx extractors.ResponseContext) (interface{}, e
 			log.Debug().
 				Str("extractorType", string(extractors.ExtractorTypeHeader)).
 				Str("headerName", e.HeaderName).
-				Str("value", value).
 				Msg("Header extracted successfully")
 			return value, nil
 		}
@@ -1,8 +1,12 @@
 package extractors
 
 import (
+	"bytes"
 	"io"
 	"net/http"
+	"sync"
+
+	"github.com/antchfx/xmlquery"
 )
 
 // concreteResponseContext implements all ResponseContext interfaces.
@@ -12,6 +16,10 @@ type concreteResponseContext struct {
 	parsedBody  interface{}
 	bodyReader  io.Reader
 	contentType string
+
+	xmlOnce sync.Once
+	xmlDoc  *xmlquery.Node
+	xmlErr  error
 }
 
 // NewResponseContext creates a new ResponseContext from an HTTP response.
@@ -79,6 +87,17 @@ func (rc *concreteResponseContext) GetParsedBody() interface{} {
 func (rc *concreteResponseContext) GetRawBody() []byte {
 	return rc.rawBody
 }
+
+// GetXMLDocument parses the raw body as XML on first call and returns the same document afterwards.
+func (rc *concreteResponseContext) GetXMLDocument() (*xmlquery.Node, error) {
+	rc.xmlOnce.Do(
+		func() {
+			rc.xmlDoc, rc.xmlErr = xmlquery.Parse(bytes.NewReader(rc.rawBody))
+		},
+	)
+	return rc.xmlDoc, rc.xmlErr
+}
+
 func (rc *concreteResponseContext) GetDuration() interface{} {
 	// Placeholder for future timing information
 	return nil
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"io"
 	"net/http"
+
+	"github.com/antchfx/xmlquery"
 )
 
 type AnyExtractor interface {
@@ -52,6 +54,12 @@ type ParsedBodyReader interface {
 	GetRawBody() []byte
 }
 
+// XMLDocumentReader provides access to the response body parsed as an XML document.
+// The body is parsed once, on first use, and the document is shared by all XPath extractors.
+type XMLDocumentReader interface {
+	GetXMLDocument() (*xmlquery.Node, error)
+}
+
 // TimingInfo provides access to response timing information.
 type TimingInfo interface {
 	GetDuration() interface{} // Can be used for future timing metrics
@@ -1,28 +1,107 @@
 package extractors
 
 import (
+	"errors"
+	"fmt"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
 	"github.com/rs/zerolog/log"
 )
 
-// XMLPathExtractor extracts values from XML using XPath expressions.
+// XMLPathExtractor extracts values from XML using XPath 1.0 expressions.
+// Namespaces maps the prefixes used in Path to namespace URIs.
 type XMLPathExtractor struct {
-	Path string `json:"path"`
+	Path       string            `json:"path"`
+	Namespaces map[string]string `json:"namespaces,omitempty"`
 }
 
-func (e XMLPathExtractor) Extract(_ ResponseContext) (interface{}, error) {
+func (e XMLPathExtractor) Extract(ctx ResponseContext) (interface{}, error) {
 	log.Debug().
 		Str("extractorType", string(ExtractorTypeXMLPath)).
 		Str("path", e.Path).
 		Msg("Starting XML path extraction")
 
-	// TODO: Implement XPath extraction
-	// Use a library like github.com/antchfx/xmlquery or similar
-	log.Error().
-		Str("extractorType", string(ExtractorTypeXMLPath)).
+	// Compile the XPath expression
+	expr, err := xpath.CompileWithNS(e.Path, e.Namespaces)
+	if err != nil {
+		err = fmt.Errorf("invalid XPath expression '%s': %w", e.Path, err)
+		log.Error().
+			Str("path", e.Path).
+			Err(err).
+			Msg("XPath compilation failed")
+		return nil, err
+	}
+
+	docReader, ok := ctx.(XMLDocumentReader)
+	if !ok {
+		errXMLDocument := errors.New("context does not support XMLDocumentReader interface")
+		log.Error().
+			Err(errXMLDocument).
+			Msg("ResponseContext does not support XMLDocumentReader")
+		return nil, errXMLDocument
+	}
+
+	doc, err := docReader.GetXMLDocument()
+	if err != nil {
+		err = fmt.Errorf("failed to parse XML from body: %w", err)
+		log.Error().
+			Err(err).
+			Msg("XML parsing failed")
+		return nil, err
+	}
+
+	// Execute the XPath query. Expressions such as count() or string() yield a scalar
+	// instead of a node-set and are returned as-is.
+	evaluated := expr.Evaluate(xmlquery.CreateXPathNavigator(doc))
+	iter, isNodeSet := evaluated.(*xpath.NodeIterator)
+	if !isNodeSet {
+		log.Debug().
+			Str("path", e.Path).
+			Msg("XPath extraction succeeded with scalar result")
+		return evaluated, nil
+	}
+
+	return e.collectNodeSet(iter)
+}
+
+// collectNodeSet converts the matched nodes to their string values. Elements yield their
+// inner text, attributes their value and text() nodes their content.
+func (e XMLPathExtractor) collectNodeSet(iter *xpath.NodeIterator) (interface{}, error) {
+	var values []interface{}
+	for iter.MoveNext() {
+		values = append(values, iter.Current().Value())
+	}
+
+	log.Debug().
+		Str("path", e.Path).
+		Int("matchCount", len(values)).
+		Msg("XPath query executed")
+
+	// Handle results
+	if len(values) == 0 {
+		xpathError := fmt.Errorf("XPath '%s' did not match any nodes", e.Path)
+		log.Warn().
+			Str("path", e.Path).
+			Err(xpathError).
+			Msg("XPath did not match any nodes")
+		return nil, xpathError
+	}
+
+	// If single result, return the value directly
+	if len(values) == 1 {
+		log.Debug().
+			Str("path", e.Path).
+			Msg("XPath extraction succeeded with single result")
+		return values[0], nil
+	}
+
+	// If multiple results, return as slice
+	log.Debug().
 		Str("path", e.Path).
-		Err(ErrNotImplemented).
-		Msg("XML path extraction not implemented")
-	return nil, ErrNotImplemented
+		Int("resultCount", len(values)).
+		Msg("XPath extraction succeeded with multiple results")
+	return values, nil
 }
 
 func (e XMLPathExtractor) GetType() ExtractorType {
@@ -0,0 +1,153 @@
+package extractors_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/extractors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ordersXML = `<?xml version="1.0" encoding="UTF-8"?>
+<orders>
+	<order id="1001" status="shipped"><total>42.50</total></order>
+	<order id="1002" status="pending"><total>10.00</total></order>
+</orders>`
+
+const soapXML = `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:m="urn:example:users">
+	<soap:Body>
+		<m:GetUserResponse>
+			<m:User id="123">Alice</m:User>
+		</m:GetUserResponse>
+	</soap:Body>
+</soap:Envelope>`
+
+func xmlContext(body string) extractors.ResponseContext {
+	resp := &http.Response{Header: http.Header{"Content-Type": []string{"application/xml"}}}
+	return extractors.NewResponseContext(resp, []byte(body), body)
+}
+
+func TestXMLPathExtractor_GetType(t *testing.T) {
+	extractor := extractors.XMLPathExtractor{Path: "/orders/order"}
+	assert.Equal(t, extractors.ExtractorTypeXMLPath, extractor.GetType())
+}
+
+func TestXMLPathExtractor_Extract_ElementText(t *testing.T) {
+	extractor := extractors.XMLPathExtractor{Path: "/orders/order[@id='1001']/total"}
+
+	result, err := extractor.Extract(xmlContext(ordersXML))
+
+	require.NoError(t, err)
+	assert.Equal(t, "42.50", result)
+}
+
+func TestXMLPathExtractor_Extract_Attribute(t *testing.T) {
+	extractor := extractors.XMLPathExtractor{Path: "/orders/order[total='10.00']/@status"}
+
+	result, err := extractor.Extract(xmlContext(ordersXML))
+
+	require.NoError(t, err)
+	assert.Equal(t, "pending", result)
+}
+
+func TestXMLPathExtractor_Extract_TextNode(t *testing.T) {
+	extractor := extractors.XMLPathExtractor{Path: "//order[1]/total/text()"}
+
+	result, err := extractor.Extract(xmlContext(ordersXML))
+
+	require.NoError(t, err)
+	assert.Equal(t, "42.50", result)
+}
+
+func TestXMLPathExtractor_Extract_MultipleResults(t *testing.T) {
+	extractor := extractors.XMLPathExtractor{Path: "//order/@id"}
+
+	result, err := extractor.Extract(xmlContext(ordersXML))
+
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"1001", "1002"}, result)
+}
+
+func TestXMLPathExtractor_Extract_Namespaces(t *testing.T) {
+	extractor := extractors.XMLPathExtractor{
+		Path: "/s:Envelope/s:Body/u:GetUserResponse/u:User",
+		Namespaces: map[string]string{
+			"s": "http://schemas.xmlsoap.org/soap/envelope/",
+			"u": "urn:example:users",
+		},
+	}
+
+	result, err := extractor.Extract(xmlContext(soapXML))
+
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", result)
+}
+
+func TestXMLPathExtractor_Extract_ScalarExpression(t *testing.T) {
+	extractor := extractors.XMLPathExtractor{Path: "count(//order)"}
+
+	result, err := extractor.Extract(xmlContext(ordersXML))
+
+	require.NoError(t, err)
+	assert.InDelta(t, 2.0, result, 0)
+}
+
+func TestXMLPathExtractor_Extract_NoMatch(t *testing.T) {
+	extractor := extractors.XMLPathExtractor{Path: "//invoice"}
+
+	result, err := extractor.Extract(xmlContext(ordersXML))
+
+	require.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "did not match any nodes")
+}
+
+func TestXMLPathExtractor_Extract_InvalidPath(t *testing.T) {
+	extractor := extractors.XMLPathExtractor{Path: "//order["}
+
+	_, err := extractor.Extract(xmlContext(ordersXML))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid XPath expression")
+}
+
+func TestXMLPathExtractor_Extract_InvalidXML(t *testing.T) {
+	extractor := extractors.XMLPathExtractor{Path: "//order"}
+
+	_, err := extractor.Extract(xmlContext("<orders><order></orders>"))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse XML")
+}
+
+func TestXMLPathExtractor_UnmarshalNamespaces(t *testing.T) {
+	extractor, err := extractors.UnmarshalExtractor(
+		[]byte(`{"type": "xmlPath", "path": "//u:User/@id", "namespaces": {"u": "urn:example:users"}}`),
+	)
+
+	require.NoError(t, err)
+	result, err := extractor.Extract(xmlContext(soapXML))
+	require.NoError(t, err)
+	assert.Equal(t, "123", result)
+}
+
+func TestXMLPathExtractor_Extract_SharesParsedDocument(t *testing.T) {
+	ctx := xmlContext(ordersXML)
+	docReader, ok := ctx.(extractors.XMLDocumentReader)
+	require.True(t, ok)
+	doc, err := docReader.GetXMLDocument()
+	require.NoError(t, err)
+
+	first, err := extractors.XMLPathExtractor{Path: "count(//order)"}.Extract(ctx)
+	require.NoError(t, err)
+	second, err := extractors.XMLPathExtractor{Path: "/orders/order[1]/@id"}.Extract(ctx)
+	require.NoError(t, err)
+
+	assert.InDelta(t, 2.0, first, 0)
+	assert.Equal(t, "1001", second)
+	again, err := docReader.GetXMLDocument()
+	require.NoError(t, err)
+	assert.Same(t, doc, again)
+}
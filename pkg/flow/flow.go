@@ -14,6 +14,28 @@ type Flow struct {
 	Nodes         []node.AnyNode         `json:"-"`
 	Edges         []edge.Edge            `json:"edges"`
 	InitialInputs map[string]interface{} `json:"initialInputs"`
+	// Inputs declares the inputs callers pass to the flow. Execute rejects inputs that do not match.
+	Inputs []InputDeclaration `json:"inputs,omitempty"`
+	// SecretInputs lists the initial inputs whose values are masked in logs and results.
+	// A dotted name such as "credentials.apiKey" designates a field of a map input.
+	SecretInputs []string `json:"secretInputs,omitempty"`
+	// Outputs declares the outputs of the flow by public name, so that callers do not depend on node IDs.
+	// Values are templates over node outputs and inputs, e.g. "{{create-user.userId}}", and may be
+	// nested in objects and arrays. They are exposed by FlowExecutionResult.Outputs.
+	Outputs  map[string]interface{} `json:"outputs,omitempty"`
+	Settings Settings               `json:"settings"`
+}
+
+// Settings holds flow-wide execution settings.
+type Settings struct {
+	// StrictTemplates fails a node when one of its templates references a variable that
+	// cannot be resolved. Defaults to true; false leaves such templates in place.
+	StrictTemplates *bool `json:"strictTemplates,omitempty"`
+}
+
+// TemplatesStrict reports whether unresolved templates fail node execution.
+func (s Settings) TemplatesStrict() bool {
+	return s.StrictTemplates == nil || *s.StrictTemplates
 }
 
 func ParseFromMap(data map[string]interface{}) (*Flow, error) {
@@ -31,6 +53,10 @@ func ParseFromJSON(data []byte) (*Flow, error) {
 		Nodes         []json.RawMessage      `json:"nodes"`
 		Edges         []edge.Edge            `json:"edges"`
 		InitialInputs map[string]interface{} `json:"initialInputs"`
+		Inputs        []InputDeclaration     `json:"inputs"`
+		SecretInputs  []string               `json:"secretInputs"`
+		Outputs       map[string]interface{} `json:"outputs"`
+		Settings      Settings               `json:"settings"`
 	}
 
 	if err := json.Unmarshal(data, &raw); err != nil {
@@ -59,5 +85,9 @@ func ParseFromJSON(data []byte) (*Flow, error) {
 		Nodes:         nodes,
 		Edges:         raw.Edges,
 		InitialInputs: raw.InitialInputs,
+		Inputs:        raw.Inputs,
+		SecretInputs:  raw.SecretInputs,
+		Outputs:       raw.Outputs,
+		Settings:      raw.Settings,
 	}, nil
 }
@@ -195,6 +195,18 @@ func TestParseFromJSON_EmptyNodes(t *testing.T) {
 	assert.Empty(t, flowResult.Edges, "should have 0 edges")
 }
 
+func TestParseFromJSON_Settings(t *testing.T) {
+	flowResult, err := flow.ParseFromJSON([]byte(`{"name": "Defaults", "nodes": [], "edges": []}`))
+	require.NoError(t, err)
+	assert.True(t, flowResult.Settings.TemplatesStrict(), "templates should be strict by default")
+
+	flowResult, err = flow.ParseFromJSON(
+		[]byte(`{"name": "Lenient", "nodes": [], "edges": [], "settings": {"strictTemplates": false}}`),
+	)
+	require.NoError(t, err)
+	assert.False(t, flowResult.Settings.TemplatesStrict())
+}
+
 func TestParseFromJSON_ExtractorTypes(t *testing.T) {
 	// Test that extractors are properly unmarshaled with correct types
 	file, err := os.ReadFile("test.json")
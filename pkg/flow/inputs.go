@@ -0,0 +1,269 @@
+package flow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// InputType is the type of a declared flow input.
+type InputType string
+
+const (
+	InputTypeString  InputType = "string"
+	InputTypeNumber  InputType = "number"
+	InputTypeInteger InputType = "integer"
+	InputTypeBoolean InputType = "boolean"
+	InputTypeObject  InputType = "object"
+	InputTypeArray   InputType = "array"
+)
+
+// IsValid reports whether the type is supported. The empty type accepts any value.
+func (t InputType) IsValid() bool {
+	switch t {
+	case "", InputTypeString, InputTypeNumber, InputTypeInteger, InputTypeBoolean, InputTypeObject, InputTypeArray:
+		return true
+	default:
+		return false
+	}
+}
+
+// InputDeclaration describes an input that callers pass to the flow.
+type InputDeclaration struct {
+	Name string `json:"name"`
+	// Type is the expected type of the value. Empty accepts any value.
+	Type     InputType `json:"type,omitempty"`
+	Required bool      `json:"required,omitempty"`
+	// Default is used when the caller does not pass the input.
+	Default interface{} `json:"default,omitempty"`
+	// Enum lists the allowed values, when the input has a fixed set of values.
+	Enum        []interface{} `json:"enum,omitempty"`
+	Description string        `json:"description,omitempty"`
+	// Secret masks the value in logs and results, like SecretInputs.
+	Secret bool `json:"secret,omitempty"`
+}
+
+// InputProblem describes why a caller input was rejected.
+type InputProblem struct {
+	Input   string `json:"input"`
+	Message string `json:"message"`
+}
+
+// InputError is returned when the inputs passed to a flow do not match its input declarations.
+// It lists every rejected input, so that callers can report all of them at once.
+type InputError struct {
+	Problems []InputProblem
+}
+
+func (e *InputError) Error() string {
+	messages := make([]string, 0, len(e.Problems))
+	for _, problem := range e.Problems {
+		messages = append(messages, fmt.Sprintf("%s: %s", problem.Input, problem.Message))
+	}
+	return "invalid flow inputs: " + strings.Join(messages, "; ")
+}
+
+// PrepareInputs checks caller inputs against the input declarations of the flow. It returns a copy
+// of inputs where declared inputs are converted to their declared type and missing ones are set to
+// their default. Inputs that are not declared are passed through unchanged.
+func (f Flow) PrepareInputs(inputs map[string]interface{}) (map[string]interface{}, error) {
+	prepared := make(map[string]interface{}, len(inputs)+len(f.Inputs))
+	for name, value := range inputs {
+		prepared[name] = value
+	}
+
+	var problems []InputProblem
+	secrets := f.SecretInputNames()
+	for _, declaration := range f.Inputs {
+		declaration.Secret = holdsSecret(secrets, declaration.Name)
+		value, err := declaration.prepare(inputs[declaration.Name])
+		if err != nil {
+			problems = append(problems, InputProblem{Input: declaration.Name, Message: err.Error()})
+			continue
+		}
+		if value != nil {
+			prepared[declaration.Name] = value
+		}
+	}
+	if len(problems) > 0 {
+		return nil, &InputError{Problems: problems}
+	}
+	return prepared, nil
+}
+
+// SecretInputNames returns the inputs whose values are masked: those listed in SecretInputs
+// and the declared inputs flagged as secret.
+func (f Flow) SecretInputNames() []string {
+	names := slices.Clone(f.SecretInputs)
+	for _, declaration := range f.Inputs {
+		if declaration.Secret && !slices.Contains(names, declaration.Name) {
+			names = append(names, declaration.Name)
+		}
+	}
+	return names
+}
+
+// holdsSecret reports whether the input is secret or contains a secret field, such as "credentials"
+// for "credentials.apiKey".
+func holdsSecret(secrets []string, name string) bool {
+	for _, secret := range secrets {
+		if secret == name || strings.HasPrefix(secret, name+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// prepare returns the value of the input given the value passed by the caller, nil if absent.
+func (d InputDeclaration) prepare(value interface{}) (interface{}, error) {
+	if value == nil {
+		if d.Default != nil {
+			return d.check(d.Default)
+		}
+		if d.Required {
+			return nil, errors.New("is required")
+		}
+		return nil, nil //nolint:nilnil // an optional input without default stays absent
+	}
+	return d.check(value)
+}
+
+// check converts value to the declared type and verifies that it is one of the allowed values.
+func (d InputDeclaration) check(value interface{}) (interface{}, error) {
+	converted, err := d.Type.convert(value, d.Secret)
+	if err != nil {
+		return nil, err
+	}
+	if len(d.Enum) == 0 {
+		return converted, nil
+	}
+	for _, allowed := range d.Enum {
+		if convertedAllowed, enumErr := d.Type.convert(allowed, d.Secret); enumErr == nil &&
+			reflect.DeepEqual(converted, convertedAllowed) {
+			return converted, nil
+		}
+	}
+	allowed, _ := json.Marshal(d.Enum)
+	return nil, fmt.Errorf("must be one of %s", allowed)
+}
+
+// convert converts value to the type. Strings are parsed for scalar types, and as JSON documents
+// for objects and arrays, so that values coming from forms or query strings are accepted. The value
+// of a secret input is never quoted in the error.
+func (t InputType) convert(value interface{}, secret bool) (interface{}, error) {
+	var converted interface{}
+	var ok bool
+	switch t {
+	case "":
+		return value, nil
+	case InputTypeString:
+		converted, ok = toString(value)
+	case InputTypeNumber:
+		converted, ok = toNumber(value)
+	case InputTypeInteger:
+		converted, ok = toInteger(value)
+	case InputTypeBoolean:
+		converted, ok = toBoolean(value)
+	case InputTypeObject:
+		converted, ok = parseJSON(value).(map[string]interface{})
+	case InputTypeArray:
+		converted, ok = parseJSON(value).([]interface{})
+	default:
+		return nil, fmt.Errorf("type '%s' is not supported", t)
+	}
+	if !ok {
+		return nil, fmt.Errorf("expected %s, got %s", t, describeValue(value, secret))
+	}
+	return converted, nil
+}
+
+func toString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case float64, int, int64, bool:
+		return fmt.Sprint(v), true
+	default:
+		return "", false
+	}
+}
+
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return number, err == nil
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func toInteger(value interface{}) (int, bool) {
+	if text, isString := value.(string); isString {
+		number, err := strconv.Atoi(strings.TrimSpace(text))
+		return number, err == nil
+	}
+	number, isNumber := toNumber(value)
+	if !isNumber || number != math.Trunc(number) {
+		return 0, false
+	}
+	return int(number), true
+}
+
+func toBoolean(value interface{}) (bool, bool) {
+	switch v := value.(type) {
+	case string:
+		boolean, err := strconv.ParseBool(strings.TrimSpace(v))
+		return boolean, err == nil
+	case bool:
+		return v, true
+	default:
+		return false, false
+	}
+}
+
+// parseJSON parses a string holding a JSON document; other values are returned unchanged.
+func parseJSON(value interface{}) interface{} {
+	text, isString := value.(string)
+	if !isString {
+		return value
+	}
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(text), &parsed); err != nil {
+		return value
+	}
+	return parsed
+}
+
+// describeValue names the JSON type of value, quoting strings so that callers see what they passed.
+// Secret strings are described by their type only.
+func describeValue(value interface{}, secret bool) string {
+	switch v := value.(type) {
+	case string:
+		if secret {
+			return "string"
+		}
+		return strconv.Quote(v)
+	case bool:
+		return "boolean"
+	case float64, int, int64:
+		return "number"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,103 @@
+package flow_test
+
+import (
+	"testing"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/flow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const inputsFlowJSON = `{
+	"name": "Inputs",
+	"inputs": [
+		{"name": "baseUrl", "type": "string", "required": true, "description": "API root"},
+		{"name": "pageSize", "type": "integer", "default": 20},
+		{"name": "ratio", "type": "number"},
+		{"name": "dryRun", "type": "boolean", "default": false},
+		{"name": "region", "type": "string", "enum": ["eu", "us"], "default": "eu"},
+		{"name": "filters", "type": "object"},
+		{"name": "ids", "type": "array"},
+		{"name": "apiKey", "type": "string", "secret": true}
+	],
+	"nodes": [{"id": "wait", "type": "delay", "data": {"duration": 1}}]
+}`
+
+func TestFlow_PrepareInputs(t *testing.T) {
+	parsed := parseFlow(t, inputsFlowJSON)
+
+	prepared, err := parsed.PrepareInputs(
+		map[string]interface{}{
+			"baseUrl":  "http://localhost",
+			"pageSize": "50",
+			"ratio":    "0.5",
+			"dryRun":   "true",
+			"filters":  `{"status": "active"}`,
+			"ids":      []interface{}{"a", "b"},
+			"extra":    "kept",
+		},
+	)
+
+	require.NoError(t, err)
+	assert.Equal(
+		t, map[string]interface{}{
+			"baseUrl":  "http://localhost",
+			"pageSize": 50,
+			"ratio":    0.5,
+			"dryRun":   true,
+			"region":   "eu",
+			"filters":  map[string]interface{}{"status": "active"},
+			"ids":      []interface{}{"a", "b"},
+			"extra":    "kept",
+		}, prepared,
+	)
+	assert.Equal(t, []string{"apiKey"}, parsed.SecretInputNames())
+}
+
+func TestFlow_PrepareInputs_InvalidSecret(t *testing.T) {
+	parsed := parseFlow(
+		t, `{
+		"name": "Secret Inputs",
+		"secretInputs": ["credentials.token"],
+		"inputs": [
+			{"name": "pin", "type": "integer", "secret": true},
+			{"name": "credentials", "type": "object"}
+		],
+		"nodes": [{"id": "wait", "type": "delay", "data": {"duration": 1}}]
+	}`,
+	)
+
+	_, err := parsed.PrepareInputs(map[string]interface{}{"pin": "k9x", "credentials": "tok-1"})
+
+	require.Error(t, err)
+	assert.Equal(
+		t, "invalid flow inputs: pin: expected integer, got string; credentials: expected object, got string",
+		err.Error(),
+	)
+}
+
+func TestFlow_PrepareInputs_Invalid(t *testing.T) {
+	parsed := parseFlow(t, inputsFlowJSON)
+
+	_, err := parsed.PrepareInputs(
+		map[string]interface{}{"pageSize": 2.5, "region": "asia", "dryRun": "maybe", "ids": "a,b"},
+	)
+
+	require.Error(t, err)
+	var inputErr *flow.InputError
+	require.ErrorAs(t, err, &inputErr)
+	assert.Equal(
+		t, []flow.InputProblem{
+			{Input: "baseUrl", Message: "is required"},
+			{Input: "pageSize", Message: "expected integer, got number"},
+			{Input: "dryRun", Message: `expected boolean, got "maybe"`},
+			{Input: "region", Message: `must be one of ["eu","us"]`},
+			{Input: "ids", Message: `expected array, got "a,b"`},
+		}, inputErr.Problems,
+	)
+	assert.Equal(
+		t, `invalid flow inputs: baseUrl: is required; pageSize: expected integer, got number; `+
+			`dryRun: expected boolean, got "maybe"; region: must be one of ["eu","us"]; ids: expected array, got "a,b"`,
+		err.Error(),
+	)
+}
@@ -0,0 +1,384 @@
+package flow
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/compatibility"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/edge"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/node"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/operators"
+)
+
+// Severity tells whether a diagnostic prevents the flow from running.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// DiagnosticCode identifies the kind of problem reported by Validate.
+type DiagnosticCode string
+
+const (
+	CodeDuplicateNodeID      DiagnosticCode = "DUPLICATE_NODE_ID"
+	CodeDuplicateEdgeID      DiagnosticCode = "DUPLICATE_EDGE_ID"
+	CodeUnknownEdgeSource    DiagnosticCode = "UNKNOWN_EDGE_SOURCE"
+	CodeUnknownEdgeTarget    DiagnosticCode = "UNKNOWN_EDGE_TARGET"
+	CodeInvalidEdgeType      DiagnosticCode = "INVALID_EDGE_TYPE"
+	CodeCycle                DiagnosticCode = "CYCLE"
+	CodeReferenceNotUpstream DiagnosticCode = "REFERENCE_NOT_UPSTREAM"
+	CodeUndeclaredOutput     DiagnosticCode = "UNDECLARED_OUTPUT"
+	CodeUnknownInput         DiagnosticCode = "UNKNOWN_INPUT"
+	CodeInvalidAssertion     DiagnosticCode = "INVALID_ASSERTION"
+	CodeIncompatibleOperator DiagnosticCode = "INCOMPATIBLE_OPERATOR"
+	CodeInvalidBranch        DiagnosticCode = "INVALID_BRANCH"
+	CodeInvalidInput         DiagnosticCode = "INVALID_INPUT"
+)
+
+// Diagnostic describes a single problem found in a flow definition.
+type Diagnostic struct {
+	Severity Severity       `json:"severity"`
+	Code     DiagnosticCode `json:"code"`
+	Message  string         `json:"message"`
+	NodeID   string         `json:"nodeId,omitempty"`
+	EdgeID   string         `json:"edgeId,omitempty"`
+}
+
+// HasErrors reports whether any of the diagnostics is an error.
+func HasErrors(diagnostics []Diagnostic) bool {
+	return slices.ContainsFunc(
+		diagnostics, func(d Diagnostic) bool { return d.Severity == SeverityError },
+	)
+}
+
+// Validate checks a flow definition without executing it and returns every problem found.
+// Errors make the flow fail at run time; warnings point at likely mistakes, such as references
+// to initial inputs the flow does not define (they may still be provided to Execute).
+func Validate(f Flow) []Diagnostic {
+	v := &validator{flow: f, nodes: make(map[string]node.AnyNode, len(f.Nodes))}
+	v.checkInputs()
+	v.checkNodes()
+	v.checkEdges()
+	v.checkCycles()
+	v.checkBranches()
+	v.checkReferences()
+	v.checkOutputs()
+	v.checkAssertions()
+	return v.diagnostics
+}
+
+type validator struct {
+	flow        Flow
+	nodes       map[string]node.AnyNode
+	validEdges  []edge.Edge
+	diagnostics []Diagnostic
+}
+
+func (v *validator) report(severity Severity, code DiagnosticCode, nodeID, edgeID, format string, args ...any) {
+	v.diagnostics = append(
+		v.diagnostics, Diagnostic{
+			Severity: severity,
+			Code:     code,
+			Message:  fmt.Sprintf(format, args...),
+			NodeID:   nodeID,
+			EdgeID:   edgeID,
+		},
+	)
+}
+
+// checkInputs verifies that input declarations are unique and that their defaults are valid.
+func (v *validator) checkInputs() {
+	names := make(map[string]bool, len(v.flow.Inputs))
+	for _, declaration := range v.flow.Inputs {
+		switch {
+		case declaration.Name == "":
+			v.report(SeverityError, CodeInvalidInput, "", "", "input declaration has no name")
+		case names[declaration.Name]:
+			v.report(SeverityError, CodeInvalidInput, "", "", "input '%s' is declared more than once", declaration.Name)
+		case !declaration.Type.IsValid():
+			v.report(
+				SeverityError, CodeInvalidInput, "", "", "input '%s' has unsupported type '%s'",
+				declaration.Name, declaration.Type,
+			)
+		case declaration.Default != nil:
+			if _, err := declaration.check(declaration.Default); err != nil {
+				v.report(SeverityError, CodeInvalidInput, "", "", "input '%s': default value %v", declaration.Name, err)
+			}
+		}
+		names[declaration.Name] = true
+	}
+}
+
+func (v *validator) checkNodes() {
+	for _, n := range v.flow.Nodes {
+		if _, exists := v.nodes[n.GetID()]; exists {
+			v.report(SeverityError, CodeDuplicateNodeID, n.GetID(), "", "node ID '%s' is used more than once", n.GetID())
+			continue
+		}
+		v.nodes[n.GetID()] = n
+	}
+}
+
+func (v *validator) checkEdges() {
+	edgeIDs := make(map[string]bool, len(v.flow.Edges))
+	for _, e := range v.flow.Edges {
+		if e.ID != "" && edgeIDs[e.ID] {
+			v.report(SeverityError, CodeDuplicateEdgeID, "", e.ID, "edge ID '%s' is used more than once", e.ID)
+		}
+		edgeIDs[e.ID] = true
+
+		valid := true
+		if _, exists := v.nodes[e.Source]; !exists {
+			v.report(SeverityError, CodeUnknownEdgeSource, "", e.ID, "edge source node '%s' does not exist", e.Source)
+			valid = false
+		}
+		if _, exists := v.nodes[e.Target]; !exists {
+			v.report(SeverityError, CodeUnknownEdgeTarget, "", e.ID, "edge target node '%s' does not exist", e.Target)
+			valid = false
+		}
+		switch e.Type {
+		case edge.TypeDefault, edge.TypeSuccess, edge.TypeFailure, "":
+		default:
+			v.report(SeverityError, CodeInvalidEdgeType, "", e.ID, "edge type '%s' is not supported", e.Type)
+		}
+		if valid {
+			v.validEdges = append(v.validEdges, e)
+		}
+	}
+}
+
+// checkCycles reports every cycle found in the graph.
+func (v *validator) checkCycles() {
+	for _, cycle := range FindCycles(v.flow.Nodes, v.validEdges) {
+		v.report(
+			SeverityError, CodeCycle, cycle[0], "", "cycle detected: %s", strings.Join(cycle, " -> "),
+		)
+	}
+}
+
+// checkBranches verifies that edges naming a branch leave a node that declares that branch.
+func (v *validator) checkBranches() {
+	for _, e := range v.validEdges {
+		if e.Branch == "" {
+			continue
+		}
+		brancher, isBrancher := v.nodes[e.Source].(node.Brancher)
+		switch {
+		case !isBrancher:
+			v.report(
+				SeverityError, CodeInvalidBranch, e.Source, e.ID,
+				"edge branch '%s' requires a condition node, but node '%s' is a %s node",
+				e.Branch, e.Source, v.nodes[e.Source].GetType(),
+			)
+		case e.IsFailure():
+			v.report(
+				SeverityError, CodeInvalidBranch, e.Source, e.ID,
+				"failure edge cannot name branch '%s'", e.Branch,
+			)
+		case !slices.Contains(brancher.BranchNames(), e.Branch):
+			v.report(
+				SeverityError, CodeInvalidBranch, e.Source, e.ID,
+				"node '%s' has no branch '%s'", e.Source, e.Branch,
+			)
+		}
+	}
+}
+
+// checkReferences verifies that every input reference points at an output declared by an upstream
+// node, or at an initial input.
+func (v *validator) checkReferences() {
+	upstream := upstreamNodes(v.validEdges)
+	for _, n := range v.flow.Nodes {
+		for _, ref := range n.InputSchema() {
+			sourceID, outputKey, isNodeRef := strings.Cut(ref, ".")
+			source, isNode := v.nodes[sourceID]
+			if !isNodeRef || !isNode {
+				if !v.hasInitialInput(ref) {
+					v.report(
+						SeverityWarning, CodeUnknownInput, n.GetID(), "",
+						"input '%s' is not a node output and not defined in the flow's initial inputs", ref,
+					)
+				}
+				continue
+			}
+			if !upstream[n.GetID()][sourceID] {
+				v.report(
+					SeverityError, CodeReferenceNotUpstream, n.GetID(), "",
+					"input '%s' references node '%s', which does not run before node '%s'", ref, sourceID, n.GetID(),
+				)
+				continue
+			}
+			if !slices.Contains(source.OutputSchema(), outputKey) {
+				v.report(
+					SeverityError, CodeUndeclaredOutput, n.GetID(), "",
+					"input '%s' references output '%s', which node '%s' does not declare", ref, outputKey, sourceID,
+				)
+			}
+		}
+	}
+}
+
+// checkOutputs verifies that the outputs declared by the flow reference declared node outputs.
+// They are resolved once every node has run, so any node of the flow may be referenced.
+func (v *validator) checkOutputs() {
+	si := &node.SchemaInference{}
+	for _, name := range slices.Sorted(maps.Keys(v.flow.Outputs)) {
+		for _, ref := range si.ExtractTemplateVariables(v.flow.Outputs[name]) {
+			sourceID, outputKey, isNodeRef := strings.Cut(ref, ".")
+			source, isNode := v.nodes[sourceID]
+			switch {
+			case !isNodeRef || !isNode:
+				if !v.hasInitialInput(ref) {
+					v.report(
+						SeverityWarning, CodeUnknownInput, "", "",
+						"flow output '%s' references '%s', which is not a node output and not a flow input", name, ref,
+					)
+				}
+			case !slices.Contains(source.OutputSchema(), outputKey):
+				v.report(
+					SeverityError, CodeUndeclaredOutput, "", "",
+					"flow output '%s' references output '%s', which node '%s' does not declare", name, outputKey, sourceID,
+				)
+			}
+		}
+	}
+}
+
+// hasInitialInput mirrors how the engine resolves initial inputs: either a key of its own
+// or a field of a map-valued input. Declared inputs are known as well.
+func (v *validator) hasInitialInput(ref string) bool {
+	if _, exists := v.flow.InitialInputs[ref]; exists {
+		return true
+	}
+	root, field, nested := strings.Cut(ref, ".")
+	for _, declaration := range v.flow.Inputs {
+		if declaration.Name == ref {
+			return true
+		}
+		// Fields of object inputs are only known at run time
+		if nested && declaration.Name == root &&
+			(declaration.Type == InputTypeObject || declaration.Type == "") {
+			return true
+		}
+	}
+	if !nested {
+		return false
+	}
+	parent, isMap := v.flow.InitialInputs[root].(map[string]interface{})
+	if !isMap {
+		return false
+	}
+	_, exists := parent[field]
+	return exists
+}
+
+func (v *validator) checkAssertions() {
+	knownOperators := knownOperatorTypes()
+	for _, n := range v.flow.Nodes {
+		for i, assertion := range n.GetAssertions() {
+			if assertion.Extractor == nil || assertion.Operator == nil {
+				v.report(
+					SeverityError, CodeInvalidAssertion, n.GetID(), "",
+					"assertion #%d must define both an extractor and an operator", i,
+				)
+				continue
+			}
+			extractorType := assertion.Extractor.GetType()
+			operatorType := assertion.Operator.GetType()
+			// Custom operators are not part of the compatibility table and cannot be checked
+			if !knownOperators[operatorType] {
+				continue
+			}
+			if !compatibility.IsOperatorCompatible(extractorType, operatorType) {
+				v.report(
+					SeverityError, CodeIncompatibleOperator, n.GetID(), "",
+					"assertion #%d: operator '%s' cannot be used with extractor '%s'", i, operatorType, extractorType,
+				)
+			}
+		}
+	}
+}
+
+// knownOperatorTypes returns every operator type listed in the compatibility table.
+func knownOperatorTypes() map[operators.OperatorType]bool {
+	known := make(map[operators.OperatorType]bool)
+	for _, compat := range compatibility.GetAllExtractorCompatibilities() {
+		for _, op := range compat.CompatibleOperators {
+			known[op] = true
+		}
+	}
+	return known
+}
+
+// upstreamNodes returns, for every node, the set of nodes that can run before it.
+func upstreamNodes(edges []edge.Edge) map[string]map[string]bool {
+	parents := make(map[string][]string)
+	for _, e := range edges {
+		parents[e.Target] = append(parents[e.Target], e.Source)
+	}
+
+	upstream := make(map[string]map[string]bool)
+	for target := range parents {
+		seen := make(map[string]bool)
+		stack := slices.Clone(parents[target])
+		for len(stack) > 0 {
+			current := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if seen[current] {
+				continue
+			}
+			seen[current] = true
+			stack = append(stack, parents[current]...)
+		}
+		upstream[target] = seen
+	}
+	return upstream
+}
+
+// FindCycles returns the cycles of the graph as node ID paths whose first and last elements are equal.
+// The graph is searched depth-first in declaration order, and every edge closing a cycle yields one path.
+// Edges must reference existing nodes.
+func FindCycles(nodes []node.AnyNode, edges []edge.Edge) [][]string {
+	children := make(map[string][]string)
+	for _, e := range edges {
+		children[e.Source] = append(children[e.Source], e.Target)
+	}
+
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+	state := make(map[string]int, len(nodes))
+	var stack []string
+	var cycles [][]string
+
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = inProgress
+		stack = append(stack, id)
+		for _, child := range children[id] {
+			switch state[child] {
+			case inProgress:
+				start := slices.Index(stack, child)
+				cycle := append(slices.Clone(stack[start:]), child)
+				cycles = append(cycles, cycle)
+			case unvisited:
+				visit(child)
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = done
+	}
+
+	for _, n := range nodes {
+		if state[n.GetID()] == unvisited {
+			visit(n.GetID())
+		}
+	}
+	return cycles
+}
@@ -0,0 +1,296 @@
+package flow_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/flow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseFlow(t *testing.T, data string) *flow.Flow {
+	t.Helper()
+	parsed, err := flow.ParseFromJSON([]byte(data))
+	require.NoError(t, err)
+	return parsed
+}
+
+func diagnosticCodes(diagnostics []flow.Diagnostic) []flow.DiagnosticCode {
+	codes := make([]flow.DiagnosticCode, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		codes = append(codes, d.Code)
+	}
+	return codes
+}
+
+func TestValidate_ValidFlow(t *testing.T) {
+	file, err := os.ReadFile("test.json")
+	require.NoError(t, err)
+	parsed, err := flow.ParseFromJSON(file)
+	require.NoError(t, err)
+
+	diagnostics := flow.Validate(*parsed)
+
+	assert.False(t, flow.HasErrors(diagnostics), "unexpected diagnostics: %v", diagnostics)
+}
+
+func TestValidate_GraphProblems(t *testing.T) {
+	parsed := parseFlow(
+		t, `{
+		"name": "Broken graph",
+		"nodes": [
+			{"id": "a", "type": "delay", "data": {"duration": 1}},
+			{"id": "b", "type": "delay", "data": {"duration": 1}},
+			{"id": "c", "type": "delay", "data": {"duration": 1}},
+			{"id": "a", "type": "delay", "data": {"duration": 1}}
+		],
+		"edges": [
+			{"id": "e1", "source": "a", "target": "b", "type": "success"},
+			{"id": "e2", "source": "b", "target": "c", "type": "success"},
+			{"id": "e3", "source": "c", "target": "a", "type": "success"},
+			{"id": "e3", "source": "a", "target": "missing", "type": "success"},
+			{"id": "e5", "source": "ghost", "target": "a", "type": "sideways"}
+		]
+	}`,
+	)
+
+	diagnostics := flow.Validate(*parsed)
+
+	require.True(t, flow.HasErrors(diagnostics))
+	assert.Equal(
+		t, []flow.Diagnostic{
+			{
+				Severity: flow.SeverityError, Code: flow.CodeDuplicateNodeID, NodeID: "a",
+				Message: "node ID 'a' is used more than once",
+			},
+			{
+				Severity: flow.SeverityError, Code: flow.CodeDuplicateEdgeID, EdgeID: "e3",
+				Message: "edge ID 'e3' is used more than once",
+			},
+			{
+				Severity: flow.SeverityError, Code: flow.CodeUnknownEdgeTarget, EdgeID: "e3",
+				Message: "edge target node 'missing' does not exist",
+			},
+			{
+				Severity: flow.SeverityError, Code: flow.CodeUnknownEdgeSource, EdgeID: "e5",
+				Message: "edge source node 'ghost' does not exist",
+			},
+			{
+				Severity: flow.SeverityError, Code: flow.CodeInvalidEdgeType, EdgeID: "e5",
+				Message: "edge type 'sideways' is not supported",
+			},
+			{
+				Severity: flow.SeverityError, Code: flow.CodeCycle, NodeID: "a",
+				Message: "cycle detected: a -> b -> c -> a",
+			},
+		}, diagnostics,
+	)
+}
+
+func TestValidate_References(t *testing.T) {
+	parsed := parseFlow(
+		t, `{
+		"name": "References",
+		"initialInputs": {"apiUrl": "http://localhost", "config": {"token": "abc"}},
+		"nodes": [
+			{
+				"id": "create-user",
+				"type": "request",
+				"outputs": [{"name": "userId", "extractor": {"type": "jsonPath", "path": "$.id"}}],
+				"data": {"method": "POST", "url": "{{apiUrl}}/users", "headers": {"Authorization": "{{config.token}}"}}
+			},
+			{
+				"id": "get-user",
+				"type": "request",
+				"data": {"method": "GET", "url": "{{apiUrl}}/users/{{create-user.userId}}/{{create-user.email}}"}
+			},
+			{
+				"id": "sibling",
+				"type": "request",
+				"data": {"method": "GET", "url": "{{baseUrl}}/{{get-user.name}}"}
+			}
+		],
+		"edges": [
+			{"id": "e1", "source": "create-user", "target": "get-user", "type": "success"},
+			{"id": "e2", "source": "create-user", "target": "sibling", "type": "success"}
+		]
+	}`,
+	)
+
+	diagnostics := flow.Validate(*parsed)
+
+	assert.ElementsMatch(
+		t, []flow.Diagnostic{
+			{
+				Severity: flow.SeverityError, Code: flow.CodeUndeclaredOutput, NodeID: "get-user",
+				Message: "input 'create-user.email' references output 'email', which node 'create-user' does not declare",
+			},
+			{
+				Severity: flow.SeverityError, Code: flow.CodeReferenceNotUpstream, NodeID: "sibling",
+				Message: "input 'get-user.name' references node 'get-user', which does not run before node 'sibling'",
+			},
+			{
+				Severity: flow.SeverityWarning, Code: flow.CodeUnknownInput, NodeID: "sibling",
+				Message: "input 'baseUrl' is not a node output and not defined in the flow's initial inputs",
+			},
+		}, diagnostics,
+	)
+}
+
+func TestValidate_Assertions(t *testing.T) {
+	parsed := parseFlow(
+		t, `{
+		"name": "Assertions",
+		"nodes": [
+			{
+				"id": "check",
+				"type": "request",
+				"assertions": [
+					{"extractor": {"type": "statusCode"}, "operator": {"type": "equals", "expected": 200}},
+					{"extractor": {"type": "statusCode"}, "operator": {"type": "regex", "pattern": "^2"}},
+					{"extractor": {"type": "statusCode"}}
+				],
+				"data": {"method": "GET", "url": "http://localhost"}
+			}
+		],
+		"edges": []
+	}`,
+	)
+
+	diagnostics := flow.Validate(*parsed)
+
+	assert.Equal(
+		t, []flow.DiagnosticCode{flow.CodeIncompatibleOperator, flow.CodeInvalidAssertion},
+		diagnosticCodes(diagnostics),
+	)
+	assert.Equal(t, "check", diagnostics[0].NodeID)
+	assert.Contains(t, diagnostics[0].Message, "assertion #1")
+}
+
+func TestValidate_Branches(t *testing.T) {
+	parsed := parseFlow(
+		t, `{
+		"name": "Branches",
+		"initialInputs": {"status": "active"},
+		"nodes": [
+			{
+				"id": "check",
+				"type": "condition",
+				"data": {
+					"branches": [{"name": "active", "when": [{"value": "{{status}}", "operator": {"type": "equals", "expected": "active"}}]}],
+					"default": "other"
+				}
+			},
+			{"id": "a", "type": "delay", "data": {"duration": 1}},
+			{"id": "b", "type": "delay", "data": {"duration": 1}},
+			{"id": "c", "type": "delay", "data": {"duration": 1}}
+		],
+		"edges": [
+			{"id": "e1", "source": "check", "target": "a", "type": "success", "branch": "active"},
+			{"id": "e2", "source": "check", "target": "b", "type": "success", "branch": "other"},
+			{"id": "e3", "source": "check", "target": "c", "type": "success", "branch": "inactive"},
+			{"id": "e4", "source": "a", "target": "c", "type": "success", "branch": "active"},
+			{"id": "e5", "source": "check", "target": "c", "type": "failure", "branch": "active"}
+		]
+	}`,
+	)
+
+	diagnostics := flow.Validate(*parsed)
+
+	assert.Equal(
+		t, []flow.Diagnostic{
+			{
+				Severity: flow.SeverityError, Code: flow.CodeInvalidBranch, NodeID: "check", EdgeID: "e3",
+				Message: "node 'check' has no branch 'inactive'",
+			},
+			{
+				Severity: flow.SeverityError, Code: flow.CodeInvalidBranch, NodeID: "a", EdgeID: "e4",
+				Message: "edge branch 'active' requires a condition node, but node 'a' is a delay node",
+			},
+			{
+				Severity: flow.SeverityError, Code: flow.CodeInvalidBranch, NodeID: "check", EdgeID: "e5",
+				Message: "failure edge cannot name branch 'active'",
+			},
+		}, diagnostics,
+	)
+}
+
+func TestValidate_Inputs(t *testing.T) {
+	parsed := parseFlow(
+		t, `{
+		"name": "Inputs",
+		"inputs": [
+			{"name": "baseUrl", "type": "string"},
+			{"name": "baseUrl", "type": "string"},
+			{"name": "limit", "type": "integer", "default": "ten"},
+			{"name": "when", "type": "date"},
+			{"name": "credentials", "type": "object"}
+		],
+		"nodes": [
+			{
+				"id": "list",
+				"type": "request",
+				"data": {"method": "GET", "url": "{{baseUrl}}/items?limit={{limit}}&user={{credentials.user}}"}
+			}
+		]
+	}`,
+	)
+
+	diagnostics := flow.Validate(*parsed)
+
+	assert.Equal(
+		t, []flow.Diagnostic{
+			{
+				Severity: flow.SeverityError, Code: flow.CodeInvalidInput,
+				Message: "input 'baseUrl' is declared more than once",
+			},
+			{
+				Severity: flow.SeverityError, Code: flow.CodeInvalidInput,
+				Message: `input 'limit': default value expected integer, got "ten"`,
+			},
+			{
+				Severity: flow.SeverityError, Code: flow.CodeInvalidInput,
+				Message: "input 'when' has unsupported type 'date'",
+			},
+		}, diagnostics,
+	)
+}
+
+func TestValidate_Outputs(t *testing.T) {
+	parsed := parseFlow(
+		t, `{
+		"name": "Outputs",
+		"inputs": [{"name": "baseUrl", "type": "string"}],
+		"nodes": [
+			{
+				"id": "create-user",
+				"type": "request",
+				"data": {"method": "POST", "url": "{{baseUrl}}/users"},
+				"outputs": [{"name": "userId", "extractor": {"type": "jsonPath", "path": "$.id"}}]
+			}
+		],
+		"outputs": {
+			"userId": "{{create-user.userId}}",
+			"profile": {"email": "{{create-user.email}}", "tenant": "{{tenant}}"},
+			"url": "{{baseUrl}}/users/{{create-user.userId}}",
+			"role": "{{default(create-user.role, 'member')}}"
+		}
+	}`,
+	)
+
+	diagnostics := flow.Validate(*parsed)
+
+	assert.Equal(
+		t, []flow.Diagnostic{
+			{
+				Severity: flow.SeverityError, Code: flow.CodeUndeclaredOutput,
+				Message: "flow output 'profile' references output 'email', which node 'create-user' does not declare",
+			},
+			{
+				Severity: flow.SeverityWarning, Code: flow.CodeUnknownInput,
+				Message: "flow output 'profile' references 'tenant', which is not a node output and not a flow input",
+			},
+		}, diagnostics,
+	)
+}
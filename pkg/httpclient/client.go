@@ -0,0 +1,170 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+const (
+	defaultMaxRedirects        = 10
+	defaultMaxIdleConnsPerHost = 16
+)
+
+// Config configures the HTTP client. The zero value gives a pooled client that follows
+// up to 10 redirects, uses the proxy from the environment and negotiates HTTP/2.
+type Config struct {
+	// Transport replaces the built-in transport, for instance to record or stub requests.
+	// Proxy, TLS, HTTP/2 and pooling settings are ignored when it is set.
+	Transport http.RoundTripper `json:"-"`
+	// ProxyURL routes every request through the given proxy. Empty uses HTTP_PROXY/HTTPS_PROXY.
+	ProxyURL string `json:"proxyUrl,omitempty"`
+	// TLS configures certificate verification and client certificates.
+	TLS *TLSConfig `json:"tls,omitempty"`
+	// FollowRedirects defaults to true. When false, redirect responses are returned as-is.
+	FollowRedirects *bool `json:"followRedirects,omitempty"`
+	// MaxRedirects is the number of redirects followed before the request fails. Zero means 10.
+	MaxRedirects int `json:"maxRedirects,omitempty"`
+	// DisableHTTP2 restricts connections to HTTP/1.1.
+	DisableHTTP2 bool `json:"disableHttp2,omitempty"`
+	// MaxIdleConnsPerHost bounds the keep-alive pool per host. Zero means 16.
+	MaxIdleConnsPerHost int `json:"maxIdleConnsPerHost,omitempty"`
+}
+
+// TLSConfig configures TLS. Certificates can be given inline as PEM or as file paths.
+type TLSConfig struct {
+	// InsecureSkipVerify disables server certificate verification. Only use it in test environments.
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+	// ServerName overrides the name used to verify the server certificate.
+	ServerName string `json:"serverName,omitempty"`
+	// CACertPEM and CACertFile add certificate authorities to the system pool.
+	CACertPEM  string `json:"caCertPem,omitempty"`
+	CACertFile string `json:"caCertFile,omitempty"`
+	// ClientCertPEM/ClientKeyPEM or ClientCertFile/ClientKeyFile enable mutual TLS.
+	ClientCertPEM  string `json:"clientCertPem,omitempty"`
+	ClientKeyPEM   string `json:"clientKeyPem,omitempty"`
+	ClientCertFile string `json:"clientCertFile,omitempty"`
+	ClientKeyFile  string `json:"clientKeyFile,omitempty"`
+}
+
+// New builds an HTTP client from the configuration.
+func New(cfg Config) (*http.Client, error) {
+	transport := cfg.Transport
+	if transport == nil {
+		built, err := cfg.buildTransport()
+		if err != nil {
+			return nil, err
+		}
+		transport = built
+	}
+
+	return &http.Client{
+		Transport:     transport,
+		CheckRedirect: cfg.checkRedirect,
+	}, nil
+}
+
+func (cfg Config) buildTransport() (*http.Transport, error) {
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return nil, errors.New("default HTTP transport is not an *http.Transport")
+	}
+	transport = transport.Clone()
+
+	transport.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	if cfg.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	}
+
+	if cfg.ProxyURL != "" {
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL '%s': %w", cfg.ProxyURL, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if cfg.TLS != nil {
+		tlsConfig, err := cfg.TLS.build()
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	// A custom TLS configuration disables HTTP/2 unless it is forced
+	transport.ForceAttemptHTTP2 = !cfg.DisableHTTP2
+	if cfg.DisableHTTP2 {
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+	return transport, nil
+}
+
+func (cfg Config) checkRedirect(_ *http.Request, via []*http.Request) error {
+	if cfg.FollowRedirects != nil && !*cfg.FollowRedirects {
+		return http.ErrUseLastResponse
+	}
+	maxRedirects := cfg.MaxRedirects
+	if maxRedirects <= 0 {
+		maxRedirects = defaultMaxRedirects
+	}
+	if len(via) > maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	return nil
+}
+
+func (t *TLSConfig) build() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify, //nolint:gosec // explicitly requested for test environments
+	}
+
+	caPEM, err := readPEM(t.CACertPEM, t.CACertFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+	if caPEM != nil {
+		pool, poolErr := x509.SystemCertPool()
+		if poolErr != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("CA certificate does not contain a valid PEM certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	certPEM, err := readPEM(t.ClientCertPEM, t.ClientCertFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client certificate: %w", err)
+	}
+	keyPEM, err := readPEM(t.ClientKeyPEM, t.ClientKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client key: %w", err)
+	}
+	if certPEM != nil || keyPEM != nil {
+		cert, keyPairErr := tls.X509KeyPair(certPEM, keyPEM)
+		if keyPairErr != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", keyPairErr)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// readPEM returns the inline PEM if set, otherwise the content of the file, or nil if neither is set.
+func readPEM(inline, path string) ([]byte, error) {
+	if inline != "" {
+		return []byte(inline), nil
+	}
+	if path == "" {
+		return nil, nil
+	}
+	return os.ReadFile(path)
+}
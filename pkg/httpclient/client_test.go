@@ -0,0 +1,238 @@
+package httpclient_test
+
+import (
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// newRedirectServer redirects /hop/N to /hop/N-1 and answers /hop/0 with 200.
+func newRedirectServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				hops := strings.TrimPrefix(r.URL.Path, "/hop/")
+				if hops == "0" {
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+				next := map[string]string{"1": "0", "2": "1", "3": "2"}[hops]
+				http.Redirect(w, r, "/hop/"+next, http.StatusFound)
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTLSServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	server := httptest.NewUnstartedServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.WriteString(w, r.Proto)
+			},
+		),
+	)
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	return server, string(caPEM)
+}
+
+func get(t *testing.T, client *http.Client, url string) (*http.Response, string, error) {
+	t.Helper()
+	resp, err := client.Get(url) //nolint:noctx // test helper
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body), nil
+}
+
+func TestNew_Redirects(t *testing.T) {
+	server := newRedirectServer(t)
+	noFollow := false
+
+	tests := []struct {
+		name           string
+		cfg            httpclient.Config
+		expectedStatus int
+		expectedErr    string
+	}{
+		{name: "follows by default", cfg: httpclient.Config{}, expectedStatus: http.StatusOK},
+		{name: "does not follow", cfg: httpclient.Config{FollowRedirects: &noFollow}, expectedStatus: http.StatusFound},
+		{name: "max hops", cfg: httpclient.Config{MaxRedirects: 2}, expectedErr: "stopped after 2 redirects"},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				client, err := httpclient.New(tt.cfg)
+				require.NoError(t, err)
+
+				resp, _, err := get(t, client, server.URL+"/hop/3")
+
+				if tt.expectedErr != "" {
+					require.Error(t, err)
+					assert.Contains(t, err.Error(), tt.expectedErr)
+					return
+				}
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			},
+		)
+	}
+}
+
+func TestNew_TLS(t *testing.T) {
+	server, caPEM := newTLSServer(t)
+
+	tests := []struct {
+		name          string
+		cfg           httpclient.Config
+		expectedProto string
+		expectedErr   string
+	}{
+		{name: "unknown authority", cfg: httpclient.Config{}, expectedErr: "certificate"},
+		{
+			name:          "custom CA negotiates HTTP/2",
+			cfg:           httpclient.Config{TLS: &httpclient.TLSConfig{CACertPEM: caPEM}},
+			expectedProto: "HTTP/2.0",
+		},
+		{
+			name:          "HTTP/2 disabled",
+			cfg:           httpclient.Config{TLS: &httpclient.TLSConfig{CACertPEM: caPEM}, DisableHTTP2: true},
+			expectedProto: "HTTP/1.1",
+		},
+		{
+			name:          "insecure skip verify",
+			cfg:           httpclient.Config{TLS: &httpclient.TLSConfig{InsecureSkipVerify: true}},
+			expectedProto: "HTTP/2.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				client, err := httpclient.New(tt.cfg)
+				require.NoError(t, err)
+
+				_, body, err := get(t, client, server.URL)
+
+				if tt.expectedErr != "" {
+					require.Error(t, err)
+					assert.Contains(t, err.Error(), tt.expectedErr)
+					return
+				}
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedProto, body)
+			},
+		)
+	}
+}
+
+func TestNew_Proxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				proxied = r.URL.String()
+				w.WriteHeader(http.StatusAccepted)
+			},
+		),
+	)
+	t.Cleanup(proxy.Close)
+
+	client, err := httpclient.New(httpclient.Config{ProxyURL: proxy.URL})
+	require.NoError(t, err)
+
+	resp, _, err := get(t, client, "http://api.example.invalid/users?id=1")
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, "http://api.example.invalid/users?id=1", proxied)
+}
+
+func TestNew_InjectedTransport(t *testing.T) {
+	var requested []string
+	client, err := httpclient.New(
+		httpclient.Config{
+			Transport: roundTripperFunc(
+				func(req *http.Request) (*http.Response, error) {
+					requested = append(requested, req.URL.String())
+					return &http.Response{
+						StatusCode: http.StatusTeapot,
+						Body:       io.NopCloser(strings.NewReader("stubbed")),
+						Request:    req,
+					}, nil
+				},
+			),
+		},
+	)
+	require.NoError(t, err)
+
+	resp, body, err := get(t, client, "http://api.example.invalid/status")
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+	assert.Equal(t, "stubbed", body)
+	assert.Equal(t, []string{"http://api.example.invalid/status"}, requested)
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         httpclient.Config
+		expectedErr string
+	}{
+		{
+			name:        "invalid CA",
+			cfg:         httpclient.Config{TLS: &httpclient.TLSConfig{CACertPEM: "not a certificate"}},
+			expectedErr: "CA certificate does not contain a valid PEM certificate",
+		},
+		{
+			name:        "missing CA file",
+			cfg:         httpclient.Config{TLS: &httpclient.TLSConfig{CACertFile: "/does/not/exist.pem"}},
+			expectedErr: "failed to read CA certificate",
+		},
+		{
+			name:        "client certificate without key",
+			cfg:         httpclient.Config{TLS: &httpclient.TLSConfig{ClientCertPEM: "not a certificate"}},
+			expectedErr: "invalid client certificate",
+		},
+		{
+			name:        "invalid proxy",
+			cfg:         httpclient.Config{ProxyURL: "://proxy"},
+			expectedErr: "invalid proxy URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				_, err := httpclient.New(tt.cfg)
+
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+			},
+		)
+	}
+}
@@ -3,21 +3,93 @@ package node
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/nanostack-dev/echopoint-flow-engine/pkg/extractors"
 	_ "github.com/nanostack-dev/echopoint-flow-engine/pkg/extractors/http" // Register HTTP extractors in init()
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/operators"
 )
 
 // CompositeAssertion combines an extractor with an operator for validation.
 type CompositeAssertion struct {
 	Extractor     extractors.AnyExtractor `json:"-"`             // The actual extractor instance
-	Operator      interface{}             `json:"-"`             // The actual operator instance (for future use)
+	Operator      operators.Operator      `json:"-"`             // The actual operator instance
 	ExtractorType string                  `json:"extractorType"` // Legacy: jsonPath, xmlPath, statusCode, header
 	ExtractorData interface{}             `json:"extractorData"` // Legacy: Configuration for the extractor
 	OperatorType  string                  `json:"operatorType"`  // equals, contains, greaterThan, etc.
 	OperatorData  interface{}             `json:"operatorData"`  // Configuration for the operator
 }
 
+// AssertionResult records the outcome of a single assertion evaluation.
+type AssertionResult struct {
+	Index         int                      `json:"index"`
+	ExtractorType extractors.ExtractorType `json:"extractor_type,omitempty"`
+	OperatorType  operators.OperatorType   `json:"operator_type,omitempty"`
+	Expected      interface{}              `json:"expected,omitempty"` // The operator configuration
+	Actual        interface{}              `json:"actual,omitempty"`   // The extracted value
+	Passed        bool                     `json:"passed"`
+	Message       string                   `json:"message,omitempty"`
+}
+
+// AssertionError is returned when one or more assertions of a node fail.
+type AssertionError struct {
+	NodeID   string
+	Failures []AssertionResult
+}
+
+func (e *AssertionError) Error() string {
+	messages := make([]string, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		messages = append(messages, fmt.Sprintf("#%d %s", failure.Index, failure.Message))
+	}
+	return fmt.Sprintf(
+		"node %s: %d assertion(s) failed: %s", e.NodeID, len(e.Failures), strings.Join(messages, "; "),
+	)
+}
+
+// Evaluate runs the extractor against the response context and validates the extracted value
+// with the operator. It never returns an error: failures are reported on the AssertionResult.
+func (ca CompositeAssertion) Evaluate(index int, respCtx extractors.ResponseContext) AssertionResult {
+	result := AssertionResult{Index: index}
+
+	if ca.Extractor == nil {
+		result.Message = "assertion has no extractor"
+		return result
+	}
+	result.ExtractorType = ca.Extractor.GetType()
+
+	if ca.Operator == nil {
+		result.Message = fmt.Sprintf("%s assertion has no operator", result.ExtractorType)
+		return result
+	}
+	result.OperatorType = ca.Operator.GetType()
+	result.Expected = ca.Operator
+
+	actual, err := ca.Extractor.Extract(respCtx)
+	if err != nil {
+		result.Message = fmt.Sprintf("%s extraction failed: %v", result.ExtractorType, err)
+		return result
+	}
+	result.Actual = actual
+
+	passed, err := ca.Operator.Validate(actual)
+	if err != nil {
+		result.Message = fmt.Sprintf(
+			"%s %s could not be evaluated: %v", result.ExtractorType, result.OperatorType, err,
+		)
+		return result
+	}
+
+	result.Passed = passed
+	if !passed {
+		expected, _ := json.Marshal(ca.Operator)
+		result.Message = fmt.Sprintf(
+			"%s %s %s failed: actual value %v", result.ExtractorType, result.OperatorType, expected, actual,
+		)
+	}
+	return result
+}
+
 // UnmarshalJSON implements custom unmarshaling for CompositeAssertion.
 func (ca *CompositeAssertion) UnmarshalJSON(data []byte) error {
 	type Alias CompositeAssertion
@@ -43,14 +115,13 @@ func (ca *CompositeAssertion) UnmarshalJSON(data []byte) error {
 		ca.Extractor = extractor
 	}
 
-	// TODO: Implement operator unmarshaling when needed
-	// For now, just store the raw operator data
+	// Unmarshal operator if provided
 	if len(aux.Operator) > 0 {
-		var opData interface{}
-		if err := json.Unmarshal(aux.Operator, &opData); err != nil {
+		operator, err := operators.UnmarshalOperator(aux.Operator)
+		if err != nil {
 			return fmt.Errorf("failed to unmarshal assertion operator: %w", err)
 		}
-		ca.Operator = opData
+		ca.Operator = operator
 	}
 
 	return nil
@@ -0,0 +1,342 @@
+package node
+
+import (
+	"cmp"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/redact"
+)
+
+// AuthType selects the authentication scheme of a request.
+type AuthType string
+
+const (
+	AuthTypeBasic                   AuthType = "basic"
+	AuthTypeBearer                  AuthType = "bearer"
+	AuthTypeAPIKey                  AuthType = "apiKey"
+	AuthTypeOAuth2ClientCredentials AuthType = "oauth2ClientCredentials"
+	AuthTypeHMAC                    AuthType = "hmac"
+)
+
+const (
+	authorizationHeader        = "Authorization"
+	defaultHMACSignatureHeader = "X-Signature"
+	defaultHMACTimestampHeader = "X-Timestamp"
+	apiKeyInQuery              = "query"
+	hmacAlgorithmSHA512        = "sha512"
+)
+
+// Auth authenticates a request. Only the fields of the selected type are used; all of them may
+// contain templates, which is how secrets are taken from flow inputs, e.g. "{{clientSecret}}".
+type Auth struct {
+	Type AuthType `json:"type"`
+
+	// Basic
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+
+	// Bearer
+	Token string `json:"token,omitempty"`
+
+	// API key: sent as header Name, or as query parameter Name when In is "query"
+	Name  string `json:"name,omitempty"`
+	Value string `json:"value,omitempty"`
+	In    string `json:"in,omitempty"`
+
+	// OAuth2 client credentials. The token is fetched once per flow run and reused until it expires.
+	TokenURL     string   `json:"tokenUrl,omitempty"`
+	ClientID     string   `json:"clientId,omitempty"`
+	ClientSecret string   `json:"clientSecret,omitempty"`
+	Scopes       []string `json:"scopes,omitempty"`
+
+	// HMAC signs "METHOD\nPATH?QUERY\nTIMESTAMP\nBODY" with Secret and sends the hex encoded
+	// signature and the Unix timestamp in SignatureHeader and TimestampHeader.
+	Secret          string `json:"secret,omitempty"`
+	Algorithm       string `json:"algorithm,omitempty"` // sha256 (default) or sha512
+	SignatureHeader string `json:"signatureHeader,omitempty"`
+	TimestampHeader string `json:"timestampHeader,omitempty"`
+}
+
+// AuthError is returned when a request cannot be authenticated.
+type AuthError struct {
+	Type AuthType
+	Err  error
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("%s authentication failed: %v", e.Type, e.Err)
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+// templateFields returns the fields that may contain templates, keyed by their JSON name.
+func (a *Auth) templateFields() map[string]*string {
+	fields := map[string]*string{
+		"username":     &a.Username,
+		"password":     &a.Password,
+		"token":        &a.Token,
+		"name":         &a.Name,
+		"value":        &a.Value,
+		"tokenUrl":     &a.TokenURL,
+		"clientId":     &a.ClientID,
+		"clientSecret": &a.ClientSecret,
+		"secret":       &a.Secret,
+	}
+	for i := range a.Scopes {
+		fields["scopes["+strconv.Itoa(i)+"]"] = &a.Scopes[i]
+	}
+	return fields
+}
+
+// resolve returns a copy of the auth block with its templates resolved.
+func (a *Auth) resolve(resolver *TemplateResolver) (*Auth, error) {
+	resolved := *a
+	resolved.Scopes = append([]string(nil), a.Scopes...)
+	for name, field := range resolved.templateFields() {
+		value, err := resolver.ResolveStringAt(*field, "auth."+name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve auth %s templates: %w", name, err)
+		}
+		*field = value
+	}
+	return &resolved, nil
+}
+
+// credentialHeaders returns the headers in which the scheme sends credentials.
+func (a *Auth) credentialHeaders() []string {
+	//nolint:exhaustive // HMAC signatures are not credentials
+	switch a.Type {
+	case AuthTypeBasic, AuthTypeBearer, AuthTypeOAuth2ClientCredentials:
+		return []string{authorizationHeader}
+	case AuthTypeAPIKey:
+		if a.In != apiKeyInQuery {
+			return []string{a.Name}
+		}
+	}
+	return nil
+}
+
+// registerSecrets makes the redactor mask the credentials of the auth block.
+func (a *Auth) registerSecrets(redactor *redact.Redactor) {
+	for _, secret := range []string{a.Password, a.Token, a.Value, a.ClientSecret, a.Secret} {
+		redactor.Add(secret)
+	}
+}
+
+// applyStatic adds the credentials of schemes that need neither the network nor the final request.
+func (a *Auth) applyStatic(headers map[string]string, queryParams map[string]interface{}) error {
+	switch a.Type {
+	case AuthTypeBasic:
+		credentials := base64.StdEncoding.EncodeToString([]byte(a.Username + ":" + a.Password))
+		headers[authorizationHeader] = "Basic " + credentials
+	case AuthTypeBearer:
+		if a.Token == "" {
+			return &AuthError{Type: a.Type, Err: errors.New("token is empty")}
+		}
+		headers[authorizationHeader] = "Bearer " + a.Token
+	case AuthTypeAPIKey:
+		if a.Name == "" {
+			return &AuthError{Type: a.Type, Err: errors.New("name is required")}
+		}
+		if a.In == apiKeyInQuery {
+			queryParams[a.Name] = a.Value
+		} else {
+			headers[a.Name] = a.Value
+		}
+	case AuthTypeOAuth2ClientCredentials, AuthTypeHMAC:
+		// Applied once the request is built, see applyDynamic
+	default:
+		return &AuthError{Type: a.Type, Err: errors.New("unsupported auth type")}
+	}
+	return nil
+}
+
+// applyDynamic adds the credentials of schemes that fetch a token or sign the final request.
+func (a *Auth) applyDynamic(
+	ctx ExecutionContext, method, requestURL string, headers map[string]string, body *requestBody,
+) error {
+	//nolint:exhaustive // the other schemes are applied by applyStatic
+	switch a.Type {
+	case AuthTypeOAuth2ClientCredentials:
+		token, err := ctx.TokenCache.token(a.tokenCacheKey(), func() (*oauth2Token, error) {
+			return a.fetchToken(ctx)
+		})
+		if err != nil {
+			return &AuthError{Type: a.Type, Err: err}
+		}
+		ctx.Redactor.Add(token)
+		headers[authorizationHeader] = "Bearer " + token
+	case AuthTypeHMAC:
+		if err := a.sign(method, requestURL, headers, body, time.Now()); err != nil {
+			return &AuthError{Type: a.Type, Err: err}
+		}
+	}
+	return nil
+}
+
+// sign adds the HMAC signature of the request to headers.
+func (a *Auth) sign(
+	method, requestURL string, headers map[string]string, body *requestBody, now time.Time,
+) error {
+	if a.Secret == "" {
+		return errors.New("secret is empty")
+	}
+	parsed, err := url.Parse(requestURL)
+	if err != nil {
+		return err
+	}
+	var newHash func() hash.Hash = sha256.New
+	switch strings.ToLower(a.Algorithm) {
+	case "", "sha256":
+	case hmacAlgorithmSHA512:
+		newHash = sha512.New
+	default:
+		return fmt.Errorf("unsupported algorithm '%s'", a.Algorithm)
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	mac := hmac.New(newHash, []byte(a.Secret))
+	_, _ = io.WriteString(mac, strings.ToUpper(method)+"\n"+parsed.RequestURI()+"\n"+timestamp+"\n")
+	if body != nil {
+		_, _ = mac.Write(body.payload)
+	}
+
+	headers[cmp.Or(a.SignatureHeader, defaultHMACSignatureHeader)] = hex.EncodeToString(mac.Sum(nil))
+	headers[cmp.Or(a.TimestampHeader, defaultHMACTimestampHeader)] = timestamp
+	return nil
+}
+
+// tokenCacheKey identifies the token of the credentials. The client secret is part of it, hashed,
+// so that nodes using different secrets for the same client never share a token.
+func (a *Auth) tokenCacheKey() string {
+	secretHash := sha256.Sum256([]byte(a.ClientSecret))
+	return strings.Join(
+		[]string{a.TokenURL, a.ClientID, hex.EncodeToString(secretHash[:]), strings.Join(a.Scopes, " ")}, "\n",
+	)
+}
+
+type oauth2Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// fetchToken requests an access token from the token endpoint.
+func (a *Auth) fetchToken(ctx ExecutionContext) (*oauth2Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", a.ClientID)
+	form.Set("client_secret", a.ClientSecret)
+	if len(a.Scopes) > 0 {
+		form.Set("scope", strings.Join(a.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(contextOf(ctx), http.MethodPost, a.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(contentTypeHeader, "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	loggerOf(ctx).Debug().
+		Str("tokenURL", a.TokenURL).
+		Str("clientID", a.ClientID).
+		Msg("Fetching OAuth2 access token")
+
+	resp, err := httpClientOf(ctx).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("token endpoint returned status %d", resp.StatusCode)
+	}
+
+	var token oauth2Token
+	if err = json.Unmarshal(respBody, &token); err != nil {
+		return nil, fmt.Errorf("invalid token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return nil, errors.New("token response has no access_token")
+	}
+	return &token, nil
+}
+
+// TokenCache shares OAuth2 access tokens between the request nodes of a flow run.
+// The engine creates one per run; a nil cache fetches a new token for every request.
+type TokenCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedToken
+}
+
+type cachedToken struct {
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// tokenExpiryMargin renews tokens shortly before they expire so they do not lapse in flight.
+const tokenExpiryMargin = 10 * time.Second
+
+// NewTokenCache creates an empty token cache.
+func NewTokenCache() *TokenCache {
+	return &TokenCache{entries: make(map[string]*cachedToken)}
+}
+
+// token returns the cached token for key, fetching it when missing or expired. Concurrent callers
+// for the same key wait for a single fetch.
+func (c *TokenCache) token(key string, fetch func() (*oauth2Token, error)) (string, error) {
+	if c == nil {
+		fetched, err := fetch()
+		if err != nil {
+			return "", err
+		}
+		return fetched.AccessToken, nil
+	}
+
+	c.mu.Lock()
+	entry, exists := c.entries[key]
+	if !exists {
+		entry = &cachedToken{}
+		c.entries[key] = entry
+	}
+	c.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.token != "" && (entry.expiresAt.IsZero() || time.Now().Before(entry.expiresAt)) {
+		return entry.token, nil
+	}
+
+	fetched, err := fetch()
+	if err != nil {
+		return "", err
+	}
+	entry.token = fetched.AccessToken
+	entry.expiresAt = time.Time{}
+	if fetched.ExpiresIn > 0 {
+		entry.expiresAt = time.Now().Add(time.Duration(fetched.ExpiresIn)*time.Second - tokenExpiryMargin)
+	}
+	return entry.token, nil
+}
@@ -0,0 +1,336 @@
+package node_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/node"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/redact"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newEchoAuthServer returns a test server recording the last request.
+func newEchoAuthServer(t *testing.T, received **http.Request, body *string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				content, _ := io.ReadAll(r.Body)
+				*received = r
+				*body = string(content)
+				w.WriteHeader(http.StatusNoContent)
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newExpiringTokenServer returns a token endpoint issuing access-1, access-2, ... tokens that expire
+// within the renewal margin of the token cache, so that every request needs a new one.
+func newExpiringTokenServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var issued atomic.Int32
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, _ *http.Request) {
+				token := "access-" + strconv.Itoa(int(issued.Add(1)))
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"access_token": "` + token + `", "expires_in": 1}`))
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRequestNode_Execute_StaticAuth(t *testing.T) {
+	var received *http.Request
+	var body string
+	server := newEchoAuthServer(t, &received, &body)
+	inputs := map[string]interface{}{"user": "alice", "password": "s3cret", "apiToken": "tok-123"}
+
+	tests := []struct {
+		name          string
+		auth          string
+		expectedQuery string
+		header        string
+		expected      string
+	}{
+		{
+			name:     "basic",
+			auth:     `{"type": "basic", "username": "{{user}}", "password": "{{password}}"}`,
+			header:   "Authorization",
+			expected: "Basic YWxpY2U6czNjcmV0",
+		},
+		{
+			name:     "bearer",
+			auth:     `{"type": "bearer", "token": "{{apiToken}}"}`,
+			header:   "Authorization",
+			expected: "Bearer tok-123",
+		},
+		{
+			name:     "api key header",
+			auth:     `{"type": "apiKey", "name": "X-Api-Key", "value": "{{apiToken}}"}`,
+			header:   "X-Api-Key",
+			expected: "tok-123",
+		},
+		{
+			name:          "api key query",
+			auth:          `{"type": "apiKey", "in": "query", "name": "api_key", "value": "{{apiToken}}"}`,
+			expectedQuery: "api_key=tok-123",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				reqNode := unmarshalRequestNode(
+					t, `{
+					"id": "secured",
+					"type": "request",
+					"data": {"method": "GET", "url": "`+server.URL+`/resource", "auth": `+tt.auth+`}
+				}`,
+				)
+
+				_, err := reqNode.Execute(node.ExecutionContext{Inputs: inputs})
+
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedQuery, received.URL.RawQuery)
+				if tt.header != "" {
+					assert.Equal(t, tt.expected, received.Header.Get(tt.header))
+				}
+			},
+		)
+	}
+}
+
+func TestRequestNode_Execute_OAuth2ClientCredentials(t *testing.T) {
+	var tokenRequests atomic.Int32
+	tokenServer := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				tokenRequests.Add(1)
+				assert.NoError(t, r.ParseForm())
+				assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+				assert.Equal(t, "my-client", r.PostForm.Get("client_id"))
+				assert.Equal(t, "client-secret", r.PostForm.Get("client_secret"))
+				assert.Equal(t, "read write", r.PostForm.Get("scope"))
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"access_token": "access-1", "token_type": "Bearer", "expires_in": 3600}`))
+			},
+		),
+	)
+	t.Cleanup(tokenServer.Close)
+
+	var received *http.Request
+	var body string
+	server := newEchoAuthServer(t, &received, &body)
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "secured",
+		"type": "request",
+		"data": {
+			"method": "GET",
+			"url": "`+server.URL+`",
+			"auth": {
+				"type": "oauth2ClientCredentials",
+				"tokenUrl": "`+tokenServer.URL+`",
+				"clientId": "my-client",
+				"clientSecret": "{{clientSecret}}",
+				"scopes": ["read", "write"]
+			}
+		}
+	}`,
+	)
+	assert.Equal(t, []string{"clientSecret"}, reqNode.InputSchema())
+
+	ctx := node.ExecutionContext{
+		Inputs:     map[string]interface{}{"clientSecret": "client-secret"},
+		TokenCache: node.NewTokenCache(),
+	}
+	for range 3 {
+		_, err := reqNode.Execute(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer access-1", received.Header.Get("Authorization"))
+	}
+	assert.Equal(t, int32(1), tokenRequests.Load(), "the token should be fetched once per run")
+}
+
+func TestRequestNode_Execute_OAuth2TokenPerClientSecret(t *testing.T) {
+	tokenServer := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				assert.NoError(t, r.ParseForm())
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"access_token": "token-` + r.PostForm.Get("client_secret") + `"}`))
+			},
+		),
+	)
+	t.Cleanup(tokenServer.Close)
+	var received *http.Request
+	var body string
+	server := newEchoAuthServer(t, &received, &body)
+	ctx := node.ExecutionContext{Inputs: map[string]interface{}{}, TokenCache: node.NewTokenCache()}
+
+	for _, secret := range []string{"good", "bad"} {
+		reqNode := unmarshalRequestNode(
+			t, `{
+			"id": "secured-`+secret+`",
+			"type": "request",
+			"data": {
+				"method": "GET",
+				"url": "`+server.URL+`",
+				"auth": {
+					"type": "oauth2ClientCredentials",
+					"tokenUrl": "`+tokenServer.URL+`",
+					"clientId": "my-client",
+					"clientSecret": "`+secret+`"
+				}
+			}
+		}`,
+		)
+
+		_, err := reqNode.Execute(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer token-"+secret, received.Header.Get("Authorization"))
+	}
+}
+
+func TestRequestNode_Execute_OAuth2TokenRenewedOnRetry(t *testing.T) {
+	tokenServer := newExpiringTokenServer(t)
+	var authorizations []string
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				authorizations = append(authorizations, r.Header.Get("Authorization"))
+				if len(authorizations) == 1 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "secured",
+		"type": "request",
+		"data": {
+			"method": "GET",
+			"url": "`+server.URL+`",
+			"retry": {"maxAttempts": 2, "backoff": {"initialDelayMs": 1}},
+			"auth": {"type": "oauth2ClientCredentials", "tokenUrl": "`+tokenServer.URL+`", "clientId": "my-client"}
+		}
+	}`,
+	)
+
+	result, err := reqNode.Execute(
+		node.ExecutionContext{Inputs: map[string]interface{}{}, TokenCache: node.NewTokenCache()},
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bearer access-1", "Bearer access-2"}, authorizations)
+	assert.Equal(t, redact.Mask, node.MustAsRequestExecutionResult(result).RequestHeaders["Authorization"])
+}
+
+func TestRequestNode_Execute_OAuth2TokenFailure(t *testing.T) {
+	tokenServer := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			},
+		),
+	)
+	t.Cleanup(tokenServer.Close)
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "secured",
+		"type": "request",
+		"data": {
+			"method": "GET",
+			"url": "http://localhost",
+			"auth": {"type": "oauth2ClientCredentials", "tokenUrl": "`+tokenServer.URL+`", "clientId": "my-client"}
+		}
+	}`,
+	)
+
+	result, err := reqNode.Execute(node.ExecutionContext{Inputs: map[string]interface{}{}})
+
+	require.Error(t, err)
+	var authErr *node.AuthError
+	require.ErrorAs(t, err, &authErr)
+	assert.Contains(t, err.Error(), "token endpoint returned status 401")
+	assert.Equal(t, node.ErrorCodeAuthFailed, *node.MustAsRequestExecutionResult(result).ErrorCode)
+}
+
+func TestRequestNode_Execute_HMACSigning(t *testing.T) {
+	var received *http.Request
+	var body string
+	server := newEchoAuthServer(t, &received, &body)
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "webhook",
+		"type": "request",
+		"data": {
+			"method": "post",
+			"url": "`+server.URL+`/hooks/{id}",
+			"pathParams": {"id": "42"},
+			"queryParams": {"v": "2"},
+			"body": {"event": "created"},
+			"auth": {"type": "hmac", "secret": "{{signingKey}}", "signatureHeader": "X-Hub-Signature"}
+		}
+	}`,
+	)
+
+	_, err := reqNode.Execute(node.ExecutionContext{Inputs: map[string]interface{}{"signingKey": "key"}})
+
+	require.NoError(t, err)
+	timestamp := received.Header.Get("X-Timestamp")
+	require.NotEmpty(t, timestamp)
+	mac := hmac.New(sha256.New, []byte("key"))
+	_, _ = io.WriteString(mac, "POST\n/hooks/42?v=2\n"+timestamp+"\n"+body)
+	assert.Equal(t, `{"event":"created"}`, body)
+	assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), received.Header.Get("X-Hub-Signature"))
+}
+
+func TestRequestNode_Execute_MasksCredentials(t *testing.T) {
+	var received *http.Request
+	var body string
+	server := newEchoAuthServer(t, &received, &body)
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "secured",
+		"type": "request",
+		"data": {
+			"method": "GET",
+			"url": "`+server.URL+`",
+			"headers": {"X-Trace": "trace-{{password}}"},
+			"auth": {"type": "basic", "username": "alice", "password": "{{password}}"}
+		}
+	}`,
+	)
+	redactor := redact.New()
+
+	result, err := reqNode.Execute(
+		node.ExecutionContext{Inputs: map[string]interface{}{"password": "s3cret"}, Redactor: redactor},
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, "Basic YWxpY2U6czNjcmV0", received.Header.Get("Authorization"))
+	assert.Equal(t, "trace-s3cret", received.Header.Get("X-Trace"))
+	headers := node.MustAsRequestExecutionResult(result).RequestHeaders
+	assert.Equal(t, redact.Mask, headers["Authorization"])
+	assert.Equal(t, "trace-***", headers["X-Trace"], "auth credentials should be masked everywhere")
+}
@@ -3,9 +3,11 @@ package node
 import "github.com/nanostack-dev/echopoint-flow-engine/pkg/extractors"
 
 // Output represents a named output with an associated extractor.
+// Secret outputs are still passed to downstream nodes, but masked in logs and results.
 type Output struct {
 	Name      string                  `json:"name"`
 	Extractor extractors.AnyExtractor `json:"extractor"`
+	Secret    bool                    `json:"secret,omitempty"`
 }
 
 // BaseNode contains common fields and behavior shared across all node types.
@@ -16,6 +18,7 @@ type BaseNode struct {
 	NodeType    Type                 `json:"type"`
 	Assertions  []CompositeAssertion `json:"assertions"`
 	Outputs     []Output             `json:"outputs"`
+	Priority    int                  `json:"priority,omitempty"`
 }
 
 // GetID returns the unique identifier for this node.
@@ -33,6 +36,11 @@ func (bn *BaseNode) GetType() Type {
 	return bn.NodeType
 }
 
+// GetPriority returns the scheduling priority of this node.
+func (bn *BaseNode) GetPriority() int {
+	return bn.Priority
+}
+
 // InputSchema returns the list of required inputs for this node
 // This method must be overridden by concrete node types to provide computed schemas
 // Format: "nodeId.outputKey" (e.g., "create-user.userId") or plain variable name.
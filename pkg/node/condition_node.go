@@ -0,0 +1,245 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/operators"
+)
+
+const (
+	conditionBranchOutput   = "branch"
+	conditionBranchesOutput = "branches"
+)
+
+type ConditionData struct {
+	// Branches are evaluated in order. By default the first matching branch is selected,
+	// like an if/else-if chain or a switch.
+	Branches []Branch `json:"branches"`
+	// MatchAll selects every matching branch instead of the first one.
+	MatchAll bool `json:"matchAll,omitempty"`
+	// Default is the branch selected when no branch matches. When empty, no branch is selected.
+	Default string `json:"default,omitempty"`
+}
+
+// Branch is selected when all of its conditions hold.
+type Branch struct {
+	Name string      `json:"name"`
+	When []Condition `json:"when"`
+}
+
+// Condition validates a value with an operator. The value usually is a template referencing
+// a flow input or an upstream output, e.g. {"value": "{{create-user.status}}",
+// "operator": {"type": "equals", "expected": "active"}}.
+type Condition struct {
+	Value    interface{}        `json:"value"`
+	Operator operators.Operator `json:"-"`
+}
+
+// UnmarshalJSON implements custom unmarshaling for Condition.
+func (c *Condition) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Value    interface{}     `json:"value"`
+		Operator json.RawMessage `json:"operator"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	c.Value = raw.Value
+	if len(raw.Operator) > 0 {
+		operator, err := operators.UnmarshalOperator(raw.Operator)
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal condition operator: %w", err)
+		}
+		c.Operator = operator
+	}
+	return nil
+}
+
+// MarshalJSON implements custom marshaling for Condition.
+func (c Condition) MarshalJSON() ([]byte, error) {
+	raw := struct {
+		Value    interface{}     `json:"value"`
+		Operator json.RawMessage `json:"operator,omitempty"`
+	}{Value: c.Value}
+	if c.Operator != nil {
+		operator, err := operators.MarshalOperator(c.Operator)
+		if err != nil {
+			return nil, err
+		}
+		raw.Operator = operator
+	}
+	return json.Marshal(raw)
+}
+
+// ConditionNode selects branches by evaluating operators on flow data. Outgoing edges naming a
+// branch are only taken when that branch is selected; the nodes behind the other branches are
+// skipped. Edges without a branch are taken whenever the node succeeds.
+type ConditionNode struct {
+	BaseNode
+
+	Data ConditionData `json:"data"`
+}
+
+// AsConditionNode safely casts an AnyNode to a ConditionNode
+// Returns the ConditionNode and true if the cast succeeds, nil and false otherwise.
+func AsConditionNode(node AnyNode) (*ConditionNode, bool) {
+	conditionNode, ok := node.(*ConditionNode)
+	return conditionNode, ok
+}
+
+// MustAsConditionNode casts an AnyNode to a ConditionNode, panicking if it fails
+// Use this when you're certain the node is a ConditionNode.
+func MustAsConditionNode(node AnyNode) *ConditionNode {
+	conditionNode, ok := AsConditionNode(node)
+	if !ok {
+		panic("expected ConditionNode but got different type")
+	}
+	return conditionNode
+}
+
+func (n *ConditionNode) GetData() ConditionData {
+	return n.Data
+}
+
+// BranchNames returns the names of the branches the node can select, including the default.
+func (n *ConditionNode) BranchNames() []string {
+	names := make([]string, 0, len(n.Data.Branches)+1)
+	for _, branch := range n.Data.Branches {
+		names = append(names, branch.Name)
+	}
+	if n.Data.Default != "" && !slices.Contains(names, n.Data.Default) {
+		names = append(names, n.Data.Default)
+	}
+	return names
+}
+
+// InputSchema infers inputs from template variables in the condition values.
+func (n *ConditionNode) InputSchema() []string {
+	si := &SchemaInference{}
+	return si.InferConditionNodeInputSchema(n.Data)
+}
+
+// OutputSchema returns the first selected branch and the list of selected branches.
+func (n *ConditionNode) OutputSchema() []string {
+	return []string{conditionBranchOutput, conditionBranchesOutput}
+}
+
+// Execute evaluates the branches and reports the selected ones.
+func (n *ConditionNode) Execute(ctx ExecutionContext) (AnyExecutionResult, error) {
+	loggerOf(ctx).Debug().
+		Str("nodeID", n.GetID()).
+		Any("inputs", ctx.Inputs).
+		Msg("Starting condition node execution")
+
+	result := &ConditionExecutionResult{
+		BaseExecutionResult: BaseExecutionResult{
+			NodeID:      n.GetID(),
+			DisplayName: n.GetDisplayName(),
+			NodeType:    TypeCondition,
+			Inputs:      ctx.Inputs,
+		},
+		SelectedBranches: []string{},
+	}
+
+	resolver := newTemplateResolver(ctx)
+	for _, branch := range n.Data.Branches {
+		evaluation, err := n.evaluateBranch(branch, resolver, ctx)
+		if err != nil {
+			errMsg := err.Error()
+			errCode := ErrorCodeConditionFailed
+			var unresolvedErr *UnresolvedTemplateError
+			if errors.As(err, &unresolvedErr) {
+				errCode = ErrorCodeUnresolvedTemplate
+			}
+			result.Error = err
+			result.ErrorMsg = &errMsg
+			result.ErrorCode = &errCode
+			result.ExecutedAt = time.Now()
+			return result, err
+		}
+		result.Evaluations = append(result.Evaluations, evaluation)
+		if evaluation.Matched {
+			result.SelectedBranches = append(result.SelectedBranches, branch.Name)
+			if !n.Data.MatchAll {
+				break
+			}
+		}
+	}
+	if len(result.SelectedBranches) == 0 && n.Data.Default != "" {
+		result.SelectedBranches = append(result.SelectedBranches, n.Data.Default)
+	}
+
+	var selected interface{}
+	if len(result.SelectedBranches) > 0 {
+		selected = result.SelectedBranches[0]
+	}
+	result.Outputs = map[string]interface{}{
+		conditionBranchOutput:   selected,
+		conditionBranchesOutput: result.SelectedBranches,
+	}
+	result.ExecutedAt = time.Now()
+
+	loggerOf(ctx).Info().
+		Str("nodeID", n.GetID()).
+		Strs("selectedBranches", result.SelectedBranches).
+		Msg("Condition node executed successfully")
+
+	return result, nil
+}
+
+// evaluateBranch evaluates the conditions of a branch, stopping at the first one that does not hold.
+// Conditions that cannot be evaluated, such as a number compared to a string, do not hold;
+// only templates that cannot be resolved fail the node.
+func (n *ConditionNode) evaluateBranch(
+	branch Branch, resolver *TemplateResolver, ctx ExecutionContext,
+) (BranchEvaluation, error) {
+	evaluation := BranchEvaluation{Branch: branch.Name, Matched: true}
+	for i, condition := range branch.When {
+		location := fmt.Sprintf("branch:%s.when[%d]", branch.Name, i)
+		actual, err := resolver.ResolveAt(condition.Value, location)
+		if err != nil {
+			return evaluation, fmt.Errorf("failed to resolve condition templates: %w", err)
+		}
+		if unresolvedErr := checkUnresolvedTemplates(n.GetID(), resolver, ctx); unresolvedErr != nil {
+			return evaluation, unresolvedErr
+		}
+
+		outcome := evaluateCondition(i, condition.Operator, actual)
+		evaluation.Conditions = append(evaluation.Conditions, outcome)
+		if !outcome.Passed {
+			evaluation.Matched = false
+			break
+		}
+	}
+
+	loggerOf(ctx).Debug().
+		Str("nodeID", n.GetID()).
+		Str("branch", branch.Name).
+		Bool("matched", evaluation.Matched).
+		Msg("Branch evaluated")
+
+	return evaluation, nil
+}
+
+func evaluateCondition(index int, operator operators.Operator, actual interface{}) AssertionResult {
+	outcome := AssertionResult{Index: index, Actual: actual}
+	if operator == nil {
+		outcome.Message = "condition has no operator"
+		return outcome
+	}
+	outcome.OperatorType = operator.GetType()
+	outcome.Expected = operator
+
+	passed, err := operator.Validate(actual)
+	if err != nil {
+		outcome.Message = fmt.Sprintf("%s could not be evaluated: %v", outcome.OperatorType, err)
+		return outcome
+	}
+	outcome.Passed = passed
+	return outcome
+}
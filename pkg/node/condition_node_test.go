@@ -0,0 +1,95 @@
+package node_test
+
+import (
+	"testing"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/node"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const statusConditionJSON = `{
+	"id": "check-status",
+	"type": "condition",
+	"data": {
+		"branches": [
+			{
+				"name": "active",
+				"when": [
+					{"value": "{{create-user.status}}", "operator": {"type": "equals", "expected": "active"}},
+					{"value": "{{create-user.age}}", "operator": {"type": "greaterThanOrEqual", "expected": 18}}
+				]
+			},
+			{"name": "pending", "when": [{"value": "{{create-user.status}}", "operator": {"type": "startsWith", "prefix": "pend"}}]},
+			{"name": "known", "when": [{"value": "{{create-user.status}}", "operator": {"type": "notEmpty"}}]}
+		],
+		"default": "unknown"
+	}
+}`
+
+func TestConditionNode_Execute(t *testing.T) {
+	tests := []struct {
+		name             string
+		matchAll         bool
+		status           interface{}
+		age              interface{}
+		expectedBranches []string
+	}{
+		{name: "first match", status: "active", age: float64(30), expectedBranches: []string{"active"}},
+		{name: "all conditions must hold", status: "active", age: float64(12), expectedBranches: []string{"known"}},
+		{name: "second branch", status: "pending", age: float64(30), expectedBranches: []string{"pending"}},
+		{
+			name: "match all", matchAll: true, status: "active", age: float64(30),
+			expectedBranches: []string{"active", "known"},
+		},
+		{name: "default", status: "", age: float64(30), expectedBranches: []string{"unknown"}},
+		{name: "type mismatch does not match", status: float64(1), age: "old", expectedBranches: []string{"unknown"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				parsed, err := node.UnmarshalNode([]byte(statusConditionJSON))
+				require.NoError(t, err)
+				conditionNode := node.MustAsConditionNode(parsed)
+				conditionNode.Data.MatchAll = tt.matchAll
+
+				result, err := conditionNode.Execute(
+					node.ExecutionContext{
+						Inputs: map[string]interface{}{"create-user.status": tt.status, "create-user.age": tt.age},
+					},
+				)
+
+				require.NoError(t, err)
+				conditionResult := node.MustAsConditionExecutionResult(result)
+				assert.Equal(t, tt.expectedBranches, conditionResult.SelectedBranches)
+				assert.Equal(t, tt.expectedBranches[0], result.GetOutputs()["branch"])
+				for _, branch := range tt.expectedBranches {
+					assert.True(t, conditionResult.IsBranchSelected(branch))
+				}
+			},
+		)
+	}
+}
+
+func TestConditionNode_Schema(t *testing.T) {
+	parsed, err := node.UnmarshalNode([]byte(statusConditionJSON))
+	require.NoError(t, err)
+	conditionNode := node.MustAsConditionNode(parsed)
+
+	assert.Equal(t, []string{"create-user.age", "create-user.status"}, conditionNode.InputSchema())
+	assert.Equal(t, []string{"branch", "branches"}, conditionNode.OutputSchema())
+	assert.Equal(t, []string{"active", "pending", "known", "unknown"}, conditionNode.BranchNames())
+}
+
+func TestConditionNode_Execute_UnresolvedTemplate(t *testing.T) {
+	parsed, err := node.UnmarshalNode([]byte(statusConditionJSON))
+	require.NoError(t, err)
+
+	result, err := parsed.Execute(node.ExecutionContext{Inputs: map[string]interface{}{}})
+
+	require.Error(t, err)
+	var unresolvedErr *node.UnresolvedTemplateError
+	require.ErrorAs(t, err, &unresolvedErr)
+	assert.Equal(t, node.ErrorCodeUnresolvedTemplate, *node.MustAsConditionExecutionResult(result).ErrorCode)
+}
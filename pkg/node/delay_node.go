@@ -3,8 +3,6 @@ package node
 import (
 	"fmt"
 	"time"
-
-	"github.com/rs/zerolog/log"
 )
 
 type DelayData struct {
@@ -50,7 +48,7 @@ func (n *DelayNode) Execute(ctx ExecutionContext) (AnyExecutionResult, error) {
 	startTime := time.Now()
 	delayMs := n.Data.Duration
 
-	log.Debug().
+	loggerOf(ctx).Debug().
 		Str("nodeID", n.GetID()).
 		Int("durationMS", delayMs).
 		Msg("Starting delay node execution")
@@ -59,7 +57,7 @@ func (n *DelayNode) Execute(ctx ExecutionContext) (AnyExecutionResult, error) {
 	for _, dep := range n.InputSchema() {
 		if _, exists := ctx.Inputs[dep]; !exists {
 			err := fmt.Errorf("missing required input: %s", dep)
-			log.Error().
+			loggerOf(ctx).Error().
 				Str("nodeID", n.GetID()).
 				Str("missingInput", dep).
 				Err(err).
@@ -68,13 +66,25 @@ func (n *DelayNode) Execute(ctx ExecutionContext) (AnyExecutionResult, error) {
 		}
 	}
 
-	log.Debug().
+	loggerOf(ctx).Debug().
 		Str("nodeID", n.GetID()).
 		Int("durationMS", delayMs).
 		Msg("Starting delay")
 
-	// Sleep for the specified duration
-	time.Sleep(time.Duration(delayMs) * time.Millisecond)
+	// Sleep for the specified duration, waking up early if the flow is cancelled
+	timer := time.NewTimer(time.Duration(delayMs) * time.Millisecond)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-contextOf(ctx).Done():
+		err := contextOf(ctx).Err()
+		loggerOf(ctx).Warn().
+			Str("nodeID", n.GetID()).
+			Int64("elapsedMs", time.Since(startTime).Milliseconds()).
+			Err(err).
+			Msg("Delay interrupted")
+		return n.createCancelledResult(ctx.Inputs, delayMs, startTime, err), err
+	}
 
 	// DelayNode typically doesn't produce outputs, but may pass through declared outputs
 	outputs := make(map[string]interface{})
@@ -97,7 +107,7 @@ func (n *DelayNode) Execute(ctx ExecutionContext) (AnyExecutionResult, error) {
 		DelayUntil: startTime.Add(time.Duration(delayMs) * time.Millisecond),
 	}
 
-	log.Info().
+	loggerOf(ctx).Info().
 		Str("nodeID", n.GetID()).
 		Int64("delayMs", result.DelayMs).
 		Msg("Delay node executed successfully")
@@ -105,6 +115,29 @@ func (n *DelayNode) Execute(ctx ExecutionContext) (AnyExecutionResult, error) {
 	return result, nil
 }
 
+// createCancelledResult creates a DelayExecutionResult for a delay interrupted by cancellation.
+func (n *DelayNode) createCancelledResult(
+	inputs map[string]interface{}, delayMs int, startTime time.Time, err error,
+) AnyExecutionResult {
+	errMsg := err.Error()
+	errCode := ErrorCodeCancelled
+
+	return &DelayExecutionResult{
+		BaseExecutionResult: BaseExecutionResult{
+			NodeID:      n.GetID(),
+			DisplayName: n.GetDisplayName(),
+			NodeType:    TypeDelay,
+			Inputs:      inputs,
+			Error:       err,
+			ErrorMsg:    &errMsg,
+			ErrorCode:   &errCode,
+			ExecutedAt:  time.Now(),
+		},
+		DelayMs:    int64(delayMs),
+		DelayUntil: startTime.Add(time.Duration(delayMs) * time.Millisecond),
+	}
+}
+
 func (n *DelayNode) GetData() DelayData {
 	return n.Data
 }
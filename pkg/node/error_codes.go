@@ -0,0 +1,31 @@
+package node
+
+// ErrorCode classifies why a node or a flow run failed. Codes are part of the result schema:
+// they never change meaning, and new codes are only added. ErrorCode is an alias of string, so the
+// ErrorCode fields of results keep their *string type.
+type ErrorCode = string
+
+// Codes reported by nodes.
+const (
+	ErrorCodeRequestFailed      ErrorCode = "REQUEST_FAILED"      // The request could not be sent or completed
+	ErrorCodeAuthFailed         ErrorCode = "AUTH_FAILED"         // Credentials of the request could not be obtained
+	ErrorCodeAssertionFailed    ErrorCode = "ASSERTION_FAILED"    // An assertion on the response did not hold
+	ErrorCodeExtractionFailed   ErrorCode = "EXTRACTION_FAILED"   // An output could not be extracted from the response
+	ErrorCodeUnresolvedTemplate ErrorCode = "UNRESOLVED_TEMPLATE" // A template references a variable that is not set
+	ErrorCodeIterationFailed    ErrorCode = "ITERATION_FAILED"    // An iteration of a forEach node failed
+	ErrorCodeConditionFailed    ErrorCode = "CONDITION_FAILED"    // The conditions of a branch could not be evaluated
+	ErrorCodeTransformFailed    ErrorCode = "TRANSFORM_FAILED"    // An expression of a transform mapping failed
+	ErrorCodeSubflowFailed      ErrorCode = "SUBFLOW_FAILED"      // The child flow of a subflow node failed
+	ErrorCodePollTimeout        ErrorCode = "POLL_TIMEOUT"        // The stop condition of a poll node never held
+	ErrorCodeCancelled          ErrorCode = "CANCELLED"           // The run was cancelled while the node was running
+	ErrorCodeTimeout            ErrorCode = "TIMEOUT"             // A deadline elapsed
+)
+
+// Codes reported by flow runs, besides CANCELLED and TIMEOUT.
+const (
+	ErrorCodeInvalidInput     ErrorCode = "INVALID_INPUT"     // The inputs do not match the input declarations
+	ErrorCodeInvalidFlow      ErrorCode = "INVALID_FLOW"      // The flow cannot run, e.g. it has no nodes
+	ErrorCodeNodeFailed       ErrorCode = "NODE_FAILED"       // A node without failure edges failed
+	ErrorCodeUnreachableNodes ErrorCode = "UNREACHABLE_NODES" // Some nodes could never be scheduled
+	ErrorCodeOutputFailed     ErrorCode = "OUTPUT_FAILED"     // A declared flow output could not be evaluated
+)
@@ -0,0 +1,716 @@
+package node
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// Template expressions are written inside {{ }} and support:
+//   - paths to inputs, with nested field and index access: {{create-user.body.items[0].id}}
+//   - string, number, boolean and null literals: {{"text"}}, {{42}}, {{true}}
+//   - function calls: {{uuid()}}, {{randomInt(1, 10)}}, {{default(user.nickname, "anonymous")}}
+//   - filters, which pass the value on their left as first argument: {{user.name | upper}}
+
+// variableNotFoundError is returned when a path does not resolve to a value.
+type variableNotFoundError struct {
+	variable string
+}
+
+func (e *variableNotFoundError) Error() string {
+	return "variable not found: " + e.variable
+}
+
+// isVariableNotFound reports whether err is a variableNotFoundError and returns the variable.
+func isVariableNotFound(err error) (string, bool) {
+	var notFound *variableNotFoundError
+	if errors.As(err, &notFound) {
+		return notFound.variable, true
+	}
+	return "", false
+}
+
+const (
+	templateOpen  = "{{"
+	templateClose = "}}"
+)
+
+// templateSegment is either literal text or a parsed {{ }} expression.
+type templateSegment struct {
+	literal string
+	expr    expression
+	source  string // The expression text between the braces
+}
+
+// expression is a parsed template expression.
+type expression interface {
+	eval(scope expressionScope) (interface{}, error)
+	// refs reports the input references the expression needs to be evaluated.
+	refs(collect func(ref string))
+}
+
+// expressionScope resolves the root of a path to a value.
+type expressionScope interface {
+	lookup(path pathExpr) (interface{}, error)
+}
+
+// parseTemplate splits s into literal text and expressions. Braces whose content is not an expression,
+// such as the {{#each items}} blocks of Mustache or Handlebars payloads, are kept as literal text.
+func parseTemplate(s string) []templateSegment {
+	var segments []templateSegment
+	rest := s
+	for {
+		start := strings.Index(rest, templateOpen)
+		if start < 0 {
+			break
+		}
+		end := findTemplateClose(rest, start+len(templateOpen))
+		if end < 0 {
+			break
+		}
+		source := rest[start+len(templateOpen) : end]
+		expr, err := parseExpression(source)
+		if err != nil {
+			segments = appendLiteral(segments, rest[:end+len(templateClose)])
+		} else {
+			segments = appendLiteral(segments, rest[:start])
+			segments = append(segments, templateSegment{expr: expr, source: source})
+		}
+		rest = rest[end+len(templateClose):]
+	}
+	return appendLiteral(segments, rest)
+}
+
+// appendLiteral appends text to segments, merging it into a trailing literal segment.
+func appendLiteral(segments []templateSegment, text string) []templateSegment {
+	if text == "" {
+		return segments
+	}
+	if last := len(segments) - 1; last >= 0 && segments[last].expr == nil {
+		segments[last].literal += text
+		return segments
+	}
+	return append(segments, templateSegment{literal: text})
+}
+
+// findTemplateClose returns the index of the }} closing an expression, skipping quoted strings.
+func findTemplateClose(s string, from int) int {
+	var quote byte
+	for i := from; i < len(s); i++ {
+		switch {
+		case quote != 0 && s[i] == '\\':
+			i++
+		case quote != 0 && s[i] == quote:
+			quote = 0
+		case quote != 0:
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case strings.HasPrefix(s[i:], templateClose):
+			return i
+		}
+	}
+	return -1
+}
+
+// ============================================================================
+// Expression nodes
+// ============================================================================
+
+type literalExpr struct {
+	value interface{}
+}
+
+func (e literalExpr) eval(_ expressionScope) (interface{}, error) { return e.value, nil }
+
+func (e literalExpr) refs(_ func(string)) {}
+
+// pathPart is a field name or, when isIndex is set, a slice index.
+type pathPart struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+type pathExpr struct {
+	parts []pathPart
+}
+
+func (e pathExpr) eval(scope expressionScope) (interface{}, error) {
+	return scope.lookup(e)
+}
+
+// refs reports the input reference of the path: the node ID and output key
+// (e.g. "create-user.body") or a single initial input name. Deeper parts are
+// navigated inside the referenced value.
+func (e pathExpr) refs(collect func(string)) {
+	const maxRefParts = 2
+	var keys []string
+	for _, part := range e.parts {
+		if part.isIndex || len(keys) == maxRefParts {
+			break
+		}
+		keys = append(keys, part.key)
+	}
+	collect(strings.Join(keys, "."))
+}
+
+func (e pathExpr) String() string {
+	var sb strings.Builder
+	for i, part := range e.parts {
+		switch {
+		case part.isIndex:
+			sb.WriteString("[" + strconv.Itoa(part.index) + "]")
+		case i > 0:
+			sb.WriteString("." + part.key)
+		default:
+			sb.WriteString(part.key)
+		}
+	}
+	return sb.String()
+}
+
+// navigate walks the remaining parts of a path inside value.
+func (e pathExpr) navigate(value interface{}, parts []pathPart) (interface{}, error) {
+	current := value
+	for _, part := range parts {
+		var found bool
+		switch typed := current.(type) {
+		case map[string]interface{}:
+			current, found = typed[part.key]
+			found = found && !part.isIndex
+		case []interface{}:
+			found = part.isIndex && part.index >= 0 && part.index < len(typed)
+			if found {
+				current = typed[part.index]
+			}
+		}
+		if !found {
+			return nil, &variableNotFoundError{variable: e.String()}
+		}
+	}
+	return current, nil
+}
+
+type callExpr struct {
+	name string
+	args []expression
+}
+
+func (e callExpr) eval(scope expressionScope) (interface{}, error) {
+	// default is evaluated lazily so that a missing first argument falls back to the second
+	if e.name == "default" {
+		return e.evalDefault(scope)
+	}
+
+	fn, exists := templateFunctions()[e.name]
+	if !exists {
+		return nil, fmt.Errorf("unknown template function '%s'", e.name)
+	}
+	args := make([]interface{}, len(e.args))
+	for i, arg := range e.args {
+		value, err := arg.eval(scope)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+	value, err := fn(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %w", e.name, err)
+	}
+	return value, nil
+}
+
+func (e callExpr) evalDefault(scope expressionScope) (interface{}, error) {
+	const defaultArgs = 2
+	if len(e.args) != defaultArgs {
+		return nil, fmt.Errorf("default(): expected 2 arguments, got %d", len(e.args))
+	}
+	value, err := e.args[0].eval(scope)
+	_, missing := isVariableNotFound(err)
+	if err != nil && !missing {
+		return nil, err
+	}
+	if missing || value == nil || value == "" {
+		return e.args[1].eval(scope)
+	}
+	return value, nil
+}
+
+// refs skips the first argument of default, which is allowed to be missing.
+func (e callExpr) refs(collect func(string)) {
+	args := e.args
+	if e.name == "default" && len(args) > 0 {
+		args = args[1:]
+	}
+	for _, arg := range args {
+		arg.refs(collect)
+	}
+}
+
+// ============================================================================
+// Functions
+// ============================================================================
+
+type templateFunction func(args []interface{}) (interface{}, error)
+
+// templateFunctions returns the built-in template functions by name.
+func templateFunctions() map[string]templateFunction {
+	return map[string]templateFunction{
+		"uuid":         fnUUID,
+		"now":          fnNow,
+		"randomInt":    fnRandomInt,
+		"base64":       stringFunction(func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }),
+		"base64Decode": fnBase64Decode,
+		"upper":        stringFunction(strings.ToUpper),
+		"lower":        stringFunction(strings.ToLower),
+		"trim":         stringFunction(strings.TrimSpace),
+		"json":         fnJSON,
+		"length":       fnLength,
+		"join":         fnJoin,
+		"concat":       fnConcat,
+		"sum":          fnSum,
+		"min":          extremumFunction(func(comparison int) bool { return comparison < 0 }),
+		"max":          extremumFunction(func(comparison int) bool { return comparison > 0 }),
+		"pluck":        fnPluck,
+	}
+}
+
+func expectArgs(args []interface{}, minArgs, maxArgs int) error {
+	if len(args) < minArgs || len(args) > maxArgs {
+		if minArgs == maxArgs {
+			return fmt.Errorf("expected %d argument(s), got %d", minArgs, len(args))
+		}
+		return fmt.Errorf("expected %d to %d arguments, got %d", minArgs, maxArgs, len(args))
+	}
+	return nil
+}
+
+// stringFunction adapts a string transformation taking a single argument.
+func stringFunction(transform func(string) string) templateFunction {
+	return func(args []interface{}) (interface{}, error) {
+		if err := expectArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		return transform(formatTemplateValue(args[0])), nil
+	}
+}
+
+func fnUUID(args []interface{}) (interface{}, error) {
+	if err := expectArgs(args, 0, 0); err != nil {
+		return nil, err
+	}
+	return uuid.NewString(), nil
+}
+
+// fnNow returns the current time as RFC 3339, as Unix seconds or milliseconds ("unix", "unixMilli"),
+// or formatted with a Go time layout.
+func fnNow(args []interface{}) (interface{}, error) {
+	if err := expectArgs(args, 0, 1); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if len(args) == 0 {
+		return now.Format(time.RFC3339), nil
+	}
+	switch layout := formatTemplateValue(args[0]); layout {
+	case "unix":
+		return now.Unix(), nil
+	case "unixMilli":
+		return now.UnixMilli(), nil
+	default:
+		return now.Format(layout), nil
+	}
+}
+
+// fnRandomInt returns a random integer between min and max, both inclusive.
+func fnRandomInt(args []interface{}) (interface{}, error) {
+	if err := expectArgs(args, 2, 2); err != nil { //nolint:mnd // min and max
+		return nil, err
+	}
+	minValue, err := toInt(args[0])
+	if err != nil {
+		return nil, err
+	}
+	maxValue, err := toInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if maxValue < minValue {
+		return nil, fmt.Errorf("max %d is lower than min %d", maxValue, minValue)
+	}
+	// The span is computed on unsigned integers: max - min overflows int for ranges wider than MaxInt.
+	span := uint64(maxValue) - uint64(minValue) //nolint:gosec // two's complement wraparound is intended
+	if span == math.MaxUint64 {
+		return int(rand.Uint64()), nil //nolint:gosec // test data does not need a secure source
+	}
+	offset := rand.Uint64N(span + 1)           //nolint:gosec // test data does not need a secure source
+	return int(uint64(minValue) + offset), nil //nolint:gosec // the sum lies between min and max
+}
+
+func fnBase64Decode(args []interface{}) (interface{}, error) {
+	if err := expectArgs(args, 1, 1); err != nil {
+		return nil, err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(formatTemplateValue(args[0]))
+	if err != nil {
+		return nil, err
+	}
+	return string(decoded), nil
+}
+
+func fnJSON(args []interface{}) (interface{}, error) {
+	if err := expectArgs(args, 1, 1); err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+func toInt(value interface{}) (int, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		return int(v), nil
+	case string:
+		return strconv.Atoi(v)
+	default:
+		return 0, fmt.Errorf("expected a number, got %T", value)
+	}
+}
+
+// formatTemplateValue renders a value embedded in a larger string.
+// Maps and slices are rendered as JSON, numbers without exponent notation.
+func formatTemplateValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case map[string]interface{}, []interface{}, map[string]string, []string:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(encoded)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// ============================================================================
+// Parser
+// ============================================================================
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenPunct
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value interface{}
+	pos   int
+}
+
+type expressionParser struct {
+	source string
+	tokens []token
+	pos    int
+}
+
+// parseExpression parses the text between {{ and }}.
+func parseExpression(source string) (expression, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template expression '%s': %w", source, err)
+	}
+	p := &expressionParser{source: source, tokens: tokens}
+	expr, err := p.parsePipeline()
+	if err == nil && p.peek().kind != tokenEOF {
+		err = p.unexpected()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid template expression '%s': %w", source, err)
+	}
+	return expr, nil
+}
+
+func (p *expressionParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *expressionParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *expressionParser) isPunct(text string) bool {
+	tok := p.peek()
+	return tok.kind == tokenPunct && tok.text == text
+}
+
+func (p *expressionParser) expectPunct(text string) error {
+	if !p.isPunct(text) {
+		return fmt.Errorf("expected '%s' at position %d", text, p.peek().pos)
+	}
+	p.next()
+	return nil
+}
+
+func (p *expressionParser) unexpected() error {
+	return unexpectedToken(p.peek())
+}
+
+func unexpectedToken(tok token) error {
+	if tok.kind == tokenEOF {
+		return errors.New("unexpected end of expression")
+	}
+	return fmt.Errorf("unexpected '%s' at position %d", tok.text, tok.pos)
+}
+
+// parsePipeline parses `primary ('|' name ['(' args ')'])*`.
+func (p *expressionParser) parsePipeline() (expression, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isPunct("|") {
+		p.next()
+		name := p.next()
+		if name.kind != tokenIdent {
+			return nil, unexpectedToken(name)
+		}
+		args := []expression{left}
+		if p.isPunct("(") {
+			extra, argsErr := p.parseArgs()
+			if argsErr != nil {
+				return nil, argsErr
+			}
+			args = append(args, extra...)
+		}
+		left = callExpr{name: name.text, args: args}
+	}
+	return left, nil
+}
+
+func (p *expressionParser) parsePrimary() (expression, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenString, tokenNumber:
+		return literalExpr{value: tok.value}, nil
+	case tokenIdent:
+		if p.isPunct("(") {
+			args, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+			return callExpr{name: tok.text, args: args}, nil
+		}
+		if keyword, ok := keywordLiteral(tok.text); ok && !p.isPunct(".") && !p.isPunct("[") {
+			return keyword, nil
+		}
+		return p.parsePath(tok.text)
+	case tokenEOF, tokenPunct:
+	}
+	return nil, unexpectedToken(tok)
+}
+
+func keywordLiteral(text string) (literalExpr, bool) {
+	switch text {
+	case "true":
+		return literalExpr{value: true}, true
+	case "false":
+		return literalExpr{value: false}, true
+	case "null":
+		return literalExpr{value: nil}, true
+	}
+	return literalExpr{}, false
+}
+
+// parseArgs parses `'(' [pipeline (',' pipeline)*] ')'`.
+func (p *expressionParser) parseArgs() ([]expression, error) {
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+	var args []expression
+	if p.isPunct(")") {
+		p.next()
+		return args, nil
+	}
+	for {
+		arg, err := p.parsePipeline()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if !p.isPunct(",") {
+			break
+		}
+		p.next()
+	}
+	return args, p.expectPunct(")")
+}
+
+// parsePath parses `ident ('.' ident | '[' number ']' | '[' string ']')*`.
+func (p *expressionParser) parsePath(root string) (expression, error) {
+	path := pathExpr{parts: []pathPart{{key: root}}}
+	for {
+		switch {
+		case p.isPunct("."):
+			p.next()
+			name := p.next()
+			if name.kind != tokenIdent {
+				return nil, unexpectedToken(name)
+			}
+			path.parts = append(path.parts, pathPart{key: name.text})
+		case p.isPunct("["):
+			p.next()
+			part, err := p.parseIndex()
+			if err != nil {
+				return nil, err
+			}
+			path.parts = append(path.parts, part)
+		default:
+			return path, nil
+		}
+	}
+}
+
+func (p *expressionParser) parseIndex() (pathPart, error) {
+	tok := p.next()
+	var part pathPart
+	switch value := tok.value.(type) {
+	case int:
+		part = pathPart{index: value, isIndex: true}
+	case string:
+		part = pathPart{key: value}
+	default:
+		return part, unexpectedToken(tok)
+	}
+	return part, p.expectPunct("]")
+}
+
+// ============================================================================
+// Lexer
+// ============================================================================
+
+func isWordChar(r rune) bool {
+	return r == '_' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case strings.ContainsRune(".,()[]|", r):
+			tokens = append(tokens, token{kind: tokenPunct, text: string(r), pos: i})
+			i++
+		case r == '"' || r == '\'':
+			tok, end, err := lexString(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = end
+		case isWordChar(r):
+			tok, end := lexWord(runes, i)
+			tokens = append(tokens, tok)
+			i = end
+		default:
+			return nil, fmt.Errorf("unexpected character '%c' at position %d", r, i)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+// lexString reads a single- or double-quoted string starting at runes[start].
+func lexString(runes []rune, start int) (token, int, error) {
+	quote := runes[start]
+	var sb strings.Builder
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				sb.WriteRune(unescapeRune(runes[i]))
+			}
+		case quote:
+			text := string(runes[start : i+1])
+			return token{kind: tokenString, text: text, value: sb.String(), pos: start}, i + 1, nil
+		default:
+			sb.WriteRune(runes[i])
+		}
+	}
+	return token{}, 0, fmt.Errorf("unterminated string at position %d", start)
+}
+
+func unescapeRune(r rune) rune {
+	switch r {
+	case 'n':
+		return '\n'
+	case 't':
+		return '\t'
+	case 'r':
+		return '\r'
+	default:
+		return r
+	}
+}
+
+// lexWord reads an identifier or a number. Identifiers may contain '-' so that
+// node IDs such as create-user can be referenced directly.
+func lexWord(runes []rune, start int) (token, int) {
+	end := start
+	for end < len(runes) && isWordChar(runes[end]) {
+		end++
+	}
+	word := string(runes[start:end])
+
+	if intValue, err := strconv.Atoi(word); err == nil {
+		// Decimal part of a number, e.g. 1.5
+		if end+1 < len(runes) && runes[end] == '.' && unicode.IsDigit(runes[end+1]) {
+			fracEnd := end + 1
+			for fracEnd < len(runes) && unicode.IsDigit(runes[fracEnd]) {
+				fracEnd++
+			}
+			text := string(runes[start:fracEnd])
+			floatValue, _ := strconv.ParseFloat(text, 64)
+			return token{kind: tokenNumber, text: text, value: floatValue, pos: start}, fracEnd
+		}
+		return token{kind: tokenNumber, text: word, value: intValue, pos: start}, end
+	}
+	return token{kind: tokenIdent, text: word, pos: start}, end
+}
@@ -0,0 +1,183 @@
+package node
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Collection functions operate on arrays, typically extracted from responses. Those taking a list
+// accept either a single array argument or the values as separate arguments:
+// {{sum(order.totals)}} and {{max(a.count, b.count)}} are both valid.
+
+// listArgs returns the values a collection function operates on.
+func listArgs(args []interface{}) []interface{} {
+	if len(args) == 1 {
+		if list, isList := args[0].([]interface{}); isList {
+			return list
+		}
+	}
+	return args
+}
+
+// fnLength returns the number of elements of an array or map, or the number of characters of a string.
+func fnLength(args []interface{}) (interface{}, error) {
+	if err := expectArgs(args, 1, 1); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case nil:
+		return 0, nil
+	case []interface{}:
+		return len(v), nil
+	case map[string]interface{}:
+		return len(v), nil
+	case string:
+		return utf8.RuneCountInString(v), nil
+	default:
+		return nil, fmt.Errorf("expected an array, a map or a string, got %T", v)
+	}
+}
+
+// fnJoin joins the elements of an array with a separator, "," by default.
+func fnJoin(args []interface{}) (interface{}, error) {
+	if err := expectArgs(args, 1, 2); err != nil { //nolint:mnd // list and optional separator
+		return nil, err
+	}
+	list, isList := args[0].([]interface{})
+	if !isList {
+		return nil, fmt.Errorf("expected an array, got %T", args[0])
+	}
+	separator := ","
+	if len(args) > 1 {
+		separator = formatTemplateValue(args[1])
+	}
+	parts := make([]string, len(list))
+	for i, item := range list {
+		parts[i] = formatTemplateValue(item)
+	}
+	return strings.Join(parts, separator), nil
+}
+
+// fnConcat concatenates arrays into a single array, or any other values into a string.
+func fnConcat(args []interface{}) (interface{}, error) {
+	allLists := len(args) > 0
+	for _, arg := range args {
+		if _, isList := arg.([]interface{}); !isList {
+			allLists = false
+			break
+		}
+	}
+	if allLists {
+		var concatenated []interface{}
+		for _, arg := range args {
+			concatenated = append(concatenated, arg.([]interface{})...) //nolint:forcetypeassert // checked above
+		}
+		return concatenated, nil
+	}
+
+	var sb strings.Builder
+	for _, arg := range args {
+		sb.WriteString(formatTemplateValue(arg))
+	}
+	return sb.String(), nil
+}
+
+// fnSum adds numbers.
+func fnSum(args []interface{}) (interface{}, error) {
+	total := 0.0
+	for _, value := range listArgs(args) {
+		number, err := toNumber(value)
+		if err != nil {
+			return nil, err
+		}
+		total += number
+	}
+	return total, nil
+}
+
+// extremumFunction returns min or max. Numbers are compared numerically and strings, such as
+// RFC 3339 timestamps, lexicographically; both cannot be mixed.
+func extremumFunction(keep func(comparison int) bool) templateFunction {
+	return func(args []interface{}) (interface{}, error) {
+		values := listArgs(args)
+		if len(values) == 0 {
+			return nil, errors.New("expected at least one value")
+		}
+		_, comparesStrings := values[0].(string)
+		best := values[0]
+		for _, value := range values[1:] {
+			comparison, err := compareValues(value, best, comparesStrings)
+			if err != nil {
+				return nil, err
+			}
+			if keep(comparison) {
+				best = value
+			}
+		}
+		if !comparesStrings {
+			return toNumber(best)
+		}
+		return best, nil
+	}
+}
+
+func compareValues(a, b interface{}, asStrings bool) (int, error) {
+	if asStrings {
+		aString, aIsString := a.(string)
+		if !aIsString {
+			return 0, fmt.Errorf("cannot compare %T with a string", a)
+		}
+		return cmp.Compare(aString, b.(string)), nil //nolint:forcetypeassert // b comes from the same list
+	}
+	aNumber, err := toNumber(a)
+	if err != nil {
+		return 0, err
+	}
+	bNumber, err := toNumber(b)
+	if err != nil {
+		return 0, err
+	}
+	return cmp.Compare(aNumber, bNumber), nil
+}
+
+// fnPluck returns the value of a field for every object of an array, null when an object lacks it.
+func fnPluck(args []interface{}) (interface{}, error) {
+	if err := expectArgs(args, 2, 2); err != nil { //nolint:mnd // list and field
+		return nil, err
+	}
+	list, isList := args[0].([]interface{})
+	if !isList {
+		return nil, fmt.Errorf("expected an array, got %T", args[0])
+	}
+	field := formatTemplateValue(args[1])
+	plucked := make([]interface{}, len(list))
+	for i, item := range list {
+		if object, isObject := item.(map[string]interface{}); isObject {
+			plucked[i] = object[field]
+		}
+	}
+	return plucked, nil
+}
+
+func toNumber(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case string:
+		number, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("expected a number, got %q", v)
+		}
+		return number, nil
+	default:
+		return 0, fmt.Errorf("expected a number, got %T", value)
+	}
+}
@@ -0,0 +1,280 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// ItemVariable holds the current item in the subflow of a forEach node, e.g. {{item.id}}.
+	ItemVariable = "item"
+	// IndexVariable holds the zero-based index of the current item, e.g. {{index}}.
+	IndexVariable = "index"
+
+	forEachResultsOutput = "results"
+	forEachCountOutput   = "count"
+)
+
+type ForEachData struct {
+	// Items is the array to iterate over, usually a template such as "{{list-users.ids}}".
+	Items interface{} `json:"items"`
+	// Concurrency is the number of iterations running at the same time. Defaults to 1.
+	Concurrency int `json:"concurrency,omitempty"`
+	// Collect names the output declared by the subflow that is gathered into results, e.g. "name".
+	// When empty, each result holds all the declared outputs of the iteration.
+	Collect string `json:"collect,omitempty"`
+	// ContinueOnError runs the remaining iterations when one fails; its result is null.
+	// Otherwise the first failure cancels the running iterations and fails the node.
+	ContinueOnError bool `json:"continueOnError,omitempty"`
+	// Subflow is run once per item.
+	Subflow Subflow `json:"subflow"`
+}
+
+// ForEachNode runs a subflow once per item of an array. It outputs the results of the
+// iterations in item order, and the number of items.
+type ForEachNode struct {
+	BaseNode
+
+	Data ForEachData `json:"data"`
+}
+
+// AsForEachNode safely casts an AnyNode to a ForEachNode
+// Returns the ForEachNode and true if the cast succeeds, nil and false otherwise.
+func AsForEachNode(node AnyNode) (*ForEachNode, bool) {
+	forEachNode, ok := node.(*ForEachNode)
+	return forEachNode, ok
+}
+
+// MustAsForEachNode casts an AnyNode to a ForEachNode, panicking if it fails
+// Use this when you're certain the node is a ForEachNode.
+func MustAsForEachNode(node AnyNode) *ForEachNode {
+	forEachNode, ok := AsForEachNode(node)
+	if !ok {
+		panic("expected ForEachNode but got different type")
+	}
+	return forEachNode
+}
+
+func (n *ForEachNode) GetData() ForEachData {
+	return n.Data
+}
+
+// GetSubflow returns the subflow run for every item.
+func (n *ForEachNode) GetSubflow() *Subflow {
+	return &n.Data.Subflow
+}
+
+// InputSchema infers inputs from the items template and from the inputs of the subflow nodes
+// that are not provided by the iteration itself.
+func (n *ForEachNode) InputSchema() []string {
+	si := &SchemaInference{}
+	return si.InferForEachNodeInputSchema(n.Data)
+}
+
+// OutputSchema returns the results of the iterations and the number of items.
+func (n *ForEachNode) OutputSchema() []string {
+	return []string{forEachResultsOutput, forEachCountOutput}
+}
+
+// Execute runs the subflow for every item and aggregates the results of the iterations.
+func (n *ForEachNode) Execute(ctx ExecutionContext) (AnyExecutionResult, error) {
+	startTime := time.Now()
+
+	loggerOf(ctx).Debug().
+		Str("nodeID", n.GetID()).
+		Any("inputs", ctx.Inputs).
+		Msg("Starting forEach node execution")
+
+	items, err := n.resolveItems(ctx)
+	if err != nil {
+		return n.createResult(ctx.Inputs, nil, nil, err, startTime), err
+	}
+	if ctx.RunSubflow == nil {
+		err = errors.New("forEach node needs a subflow runner, run it through the flow engine")
+		return n.createResult(ctx.Inputs, items, nil, err, startTime), err
+	}
+
+	iterations, err := n.runIterations(ctx, items)
+	if err != nil {
+		loggerOf(ctx).Error().
+			Str("nodeID", n.GetID()).
+			Int("itemCount", len(items)).
+			Err(err).
+			Msg("forEach node failed")
+		return n.createResult(ctx.Inputs, items, iterations, err, startTime), err
+	}
+
+	result := n.createResult(ctx.Inputs, items, iterations, nil, startTime)
+	result.Outputs = map[string]interface{}{
+		forEachResultsOutput: n.collectResults(items, iterations),
+		forEachCountOutput:   len(items),
+	}
+
+	loggerOf(ctx).Info().
+		Str("nodeID", n.GetID()).
+		Int("itemCount", len(items)).
+		Int64("durationMs", result.DurationMs).
+		Msg("forEach node executed successfully")
+
+	return result, nil
+}
+
+// resolveItems resolves the items template, which must produce an array.
+func (n *ForEachNode) resolveItems(ctx ExecutionContext) ([]interface{}, error) {
+	resolver := newTemplateResolver(ctx)
+	resolved, err := resolver.ResolveAt(n.Data.Items, "items")
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve items templates: %w", err)
+	}
+	if unresolvedErr := checkUnresolvedTemplates(n.GetID(), resolver, ctx); unresolvedErr != nil {
+		return nil, unresolvedErr
+	}
+
+	items, isArray := resolved.([]interface{})
+	if !isArray {
+		return nil, fmt.Errorf("items must resolve to an array, got %T", resolved)
+	}
+	return items, nil
+}
+
+// runIterations runs the subflow for every item, at most Concurrency at a time. Iterations are
+// returned in item order; those that were never started are left out.
+func (n *ForEachNode) runIterations(ctx ExecutionContext, items []interface{}) ([]IterationResult, error) {
+	iterCtx, cancel := context.WithCancel(contextOf(ctx))
+	defer cancel()
+
+	concurrency := max(n.Data.Concurrency, 1)
+	slots := make(chan struct{}, concurrency)
+	iterations := make([]*IterationResult, len(items))
+	var (
+		wg       sync.WaitGroup
+		failOnce sync.Once
+		failure  error
+	)
+
+	for index, item := range items {
+		select {
+		case slots <- struct{}{}:
+		case <-iterCtx.Done():
+		}
+		if iterCtx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			iteration, err := n.runIteration(iterCtx, ctx, index, item)
+			iterations[index] = iteration
+			if err == nil {
+				return
+			}
+			loggerOf(ctx).Warn().
+				Str("nodeID", n.GetID()).
+				Int("index", index).
+				Err(err).
+				Msg("forEach iteration failed")
+			if !n.Data.ContinueOnError {
+				failOnce.Do(
+					func() {
+						failure = fmt.Errorf("iteration %d failed: %w", index, err)
+						cancel()
+					},
+				)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if failure == nil {
+		failure = contextOf(ctx).Err()
+	}
+
+	started := make([]IterationResult, 0, len(items))
+	for _, iteration := range iterations {
+		if iteration != nil {
+			started = append(started, *iteration)
+		}
+	}
+	return started, failure
+}
+
+// runIteration runs the subflow for a single item. The subflow sees the inputs of the
+// forEach node along with the item and its index.
+func (n *ForEachNode) runIteration(
+	iterCtx context.Context, ctx ExecutionContext, index int, item interface{},
+) (*IterationResult, error) {
+	inputs := make(map[string]interface{}, len(ctx.Inputs)+2)
+	for key, value := range ctx.Inputs {
+		inputs[key] = value
+	}
+	inputs[ItemVariable] = item
+	inputs[IndexVariable] = index
+
+	loggerOf(ctx).Debug().
+		Str("nodeID", n.GetID()).
+		Int("index", index).
+		Msg("Starting forEach iteration")
+
+	result, err := ctx.RunSubflow(iterCtx, inputs)
+	return &IterationResult{Index: index, Item: item, Result: result}, err
+}
+
+// collectResults returns the result of every item, in item order. Failed iterations yield nil.
+func (n *ForEachNode) collectResults(items []interface{}, iterations []IterationResult) []interface{} {
+	results := make([]interface{}, len(items))
+	for _, iteration := range iterations {
+		if iteration.Result == nil || !iteration.Result.Success {
+			continue
+		}
+		if n.Data.Collect == "" {
+			results[iteration.Index] = iteration.Result.Outputs
+		} else {
+			results[iteration.Index], _ = iteration.Result.Output(n.Data.Collect)
+		}
+	}
+	return results
+}
+
+func (n *ForEachNode) createResult(
+	inputs map[string]interface{},
+	items []interface{},
+	iterations []IterationResult,
+	err error,
+	startTime time.Time,
+) *ForEachExecutionResult {
+	result := &ForEachExecutionResult{
+		BaseExecutionResult: BaseExecutionResult{
+			NodeID:      n.GetID(),
+			DisplayName: n.GetDisplayName(),
+			NodeType:    TypeForEach,
+			Inputs:      inputs,
+			ExecutedAt:  time.Now(),
+		},
+		ItemCount:  len(items),
+		Iterations: iterations,
+		DurationMs: time.Since(startTime).Milliseconds(),
+	}
+	if err != nil {
+		errMsg := err.Error()
+		errCode := ErrorCodeIterationFailed
+		var unresolvedErr *UnresolvedTemplateError
+		switch {
+		case errors.As(err, &unresolvedErr):
+			errCode = ErrorCodeUnresolvedTemplate
+		case errors.Is(err, context.Canceled):
+			errCode = ErrorCodeCancelled
+		case errors.Is(err, context.DeadlineExceeded):
+			errCode = ErrorCodeTimeout
+		}
+		result.Error = err
+		result.ErrorMsg = &errMsg
+		result.ErrorCode = &errCode
+	}
+	return result
+}
@@ -0,0 +1,160 @@
+package node_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/node"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func unmarshalForEachNode(t *testing.T, data string) *node.ForEachNode {
+	t.Helper()
+	parsed, err := node.UnmarshalNode([]byte(data))
+	require.NoError(t, err)
+	return node.MustAsForEachNode(parsed)
+}
+
+// doublingSubflow returns a subflow runner whose declared "value" output is twice the item.
+func doublingSubflow(running, maxRunning *atomic.Int32) node.SubflowRunner {
+	return func(ctx context.Context, inputs map[string]interface{}) (*node.FlowExecutionResult, error) {
+		current := running.Add(1)
+		defer running.Add(-1)
+		for {
+			seen := maxRunning.Load()
+			if current <= seen || maxRunning.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+		select {
+		case <-time.After(10 * time.Millisecond):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		item, _ := inputs["item"].(float64)
+		if item < 0 {
+			err := errors.New("negative item")
+			return &node.FlowExecutionResult{Error: err}, err
+		}
+		return &node.FlowExecutionResult{
+			Outputs: map[string]interface{}{"value": item * 2, "index": inputs["index"]},
+			Success: true,
+		}, nil
+	}
+}
+
+func TestForEachNode_Execute(t *testing.T) {
+	forEachNode := unmarshalForEachNode(
+		t, `{
+		"id": "loop",
+		"type": "forEach",
+		"data": {
+			"items": "{{list.values}}",
+			"concurrency": 2,
+			"collect": "value",
+			"subflow": {
+				"nodes": [
+					{"id": "double", "type": "request", "data": {"method": "GET", "url": "{{baseUrl}}/{{item}}?i={{index}}"}}
+				]
+			}
+		}
+	}`,
+	)
+	assert.Equal(t, []string{"baseUrl", "list.values"}, forEachNode.InputSchema())
+	assert.Equal(t, []string{"results", "count"}, forEachNode.OutputSchema())
+
+	var running, maxRunning atomic.Int32
+	result, err := forEachNode.Execute(
+		node.ExecutionContext{
+			Inputs: map[string]interface{}{
+				"list.values": []interface{}{float64(1), float64(2), float64(3), float64(4), float64(5)},
+				"baseUrl":     "http://localhost",
+			},
+			RunSubflow: doublingSubflow(&running, &maxRunning),
+		},
+	)
+
+	require.NoError(t, err)
+	assert.Equal(
+		t, []interface{}{float64(2), float64(4), float64(6), float64(8), float64(10)},
+		result.GetOutputs()["results"],
+	)
+	assert.Equal(t, 5, result.GetOutputs()["count"])
+	assert.Equal(t, int32(2), maxRunning.Load())
+
+	forEachResult := node.MustAsForEachExecutionResult(result)
+	require.Len(t, forEachResult.Iterations, 5)
+	for i, iteration := range forEachResult.Iterations {
+		assert.Equal(t, i, iteration.Index)
+		assert.Equal(t, i, iteration.Result.Outputs["index"])
+	}
+}
+
+func TestForEachNode_Execute_IterationFailure(t *testing.T) {
+	tests := []struct {
+		name            string
+		continueOnError bool
+		expectedErr     string
+		expectedResults []interface{}
+	}{
+		{name: "fails the node", expectedErr: "iteration 1 failed: negative item"},
+		{
+			name:            "continue on error",
+			continueOnError: true,
+			expectedResults: []interface{}{
+				map[string]interface{}{"value": float64(2), "index": 0},
+				nil,
+				map[string]interface{}{"value": float64(6), "index": 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				forEachNode := &node.ForEachNode{
+					BaseNode: node.BaseNode{ID: "loop", NodeType: node.TypeForEach},
+					Data: node.ForEachData{
+						Items:           []interface{}{float64(1), float64(-1), float64(3)},
+						ContinueOnError: tt.continueOnError,
+					},
+				}
+
+				var running, maxRunning atomic.Int32
+				result, err := forEachNode.Execute(
+					node.ExecutionContext{RunSubflow: doublingSubflow(&running, &maxRunning)},
+				)
+
+				if tt.expectedErr != "" {
+					require.Error(t, err)
+					assert.Equal(t, tt.expectedErr, err.Error())
+					forEachResult := node.MustAsForEachExecutionResult(result)
+					assert.Equal(t, node.ErrorCodeIterationFailed, *forEachResult.ErrorCode)
+					assert.Len(t, forEachResult.Iterations, 2, "no iteration should start after a failure")
+					return
+				}
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedResults, result.GetOutputs()["results"])
+			},
+		)
+	}
+}
+
+func TestForEachNode_Execute_ItemsNotAnArray(t *testing.T) {
+	forEachNode := &node.ForEachNode{
+		BaseNode: node.BaseNode{ID: "loop", NodeType: node.TypeForEach},
+		Data:     node.ForEachData{Items: "{{user}}"},
+	}
+
+	_, err := forEachNode.Execute(
+		node.ExecutionContext{Inputs: map[string]interface{}{"user": map[string]interface{}{"id": "1"}}},
+	)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "items must resolve to an array, got map[string]interface {}")
+}
@@ -0,0 +1,312 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/extractors"
+)
+
+const (
+	pollResponseOutput = "response"
+	pollAttemptsOutput = "attempts"
+)
+
+type PollData struct {
+	// Request is sent on every poll. Its retry policy applies within a poll, to transient failures.
+	// OAuth2 tokens and HMAC signatures are renewed for every poll.
+	Request RequestData `json:"request"`
+	// Until is the stop condition: polling stops once all of its assertions pass,
+	// e.g. a jsonPath extractor on "$.status" with an equals "done" operator.
+	Until []CompositeAssertion `json:"until"`
+	// IntervalMs is the delay between two polls.
+	IntervalMs int `json:"intervalMs"`
+	// Backoff makes the delay grow from one poll to the next; by default it is fixed.
+	Backoff *PollBackoff `json:"backoff,omitempty"`
+	// TimeoutMs bounds the total polling time. No poll starts after it elapses.
+	TimeoutMs int `json:"timeoutMs,omitempty"`
+	// MaxAttempts bounds the number of polls. At least one of TimeoutMs and MaxAttempts must be set.
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+}
+
+// PollBackoff describes how the delay between polls grows.
+type PollBackoff struct {
+	Multiplier    float64 `json:"multiplier,omitempty"`    // Growth factor, defaults to 2
+	MaxIntervalMs int     `json:"maxIntervalMs,omitempty"` // Upper bound for the delay, 30s by default
+	Jitter        float64 `json:"jitter,omitempty"`        // Random spread as a fraction of the delay (0..1)
+}
+
+// PollNode sends a request until a stop condition holds, for APIs that complete work asynchronously.
+// Assertions and outputs of the node apply to the response of the last poll. Besides its declared
+// outputs, the node outputs that response and the number of polls.
+type PollNode struct {
+	BaseNode
+
+	Data PollData `json:"data"`
+}
+
+// AsPollNode safely casts an AnyNode to a PollNode
+// Returns the PollNode and true if the cast succeeds, nil and false otherwise.
+func AsPollNode(node AnyNode) (*PollNode, bool) {
+	pollNode, ok := node.(*PollNode)
+	return pollNode, ok
+}
+
+// MustAsPollNode casts an AnyNode to a PollNode, panicking if it fails
+// Use this when you're certain the node is a PollNode.
+func MustAsPollNode(node AnyNode) *PollNode {
+	pollNode, ok := AsPollNode(node)
+	if !ok {
+		panic("expected PollNode but got different type")
+	}
+	return pollNode
+}
+
+func (n *PollNode) GetData() PollData {
+	return n.Data
+}
+
+// InputSchema infers inputs from template variables in the request.
+func (n *PollNode) InputSchema() []string {
+	si := &SchemaInference{}
+	return si.InferRequestNodeInputSchema(n.Data.Request)
+}
+
+// OutputSchema returns the declared outputs, the last response and the number of polls.
+func (n *PollNode) OutputSchema() []string {
+	si := &SchemaInference{}
+	return append(si.InferRequestNodeOutputSchema(n.GetOutputs()), pollResponseOutput, pollAttemptsOutput)
+}
+
+// Execute polls until the stop condition holds, then checks and extracts the last response.
+func (n *PollNode) Execute(ctx ExecutionContext) (AnyExecutionResult, error) {
+	startTime := time.Now()
+
+	loggerOf(ctx).Debug().
+		Str("nodeID", n.GetID()).
+		Any("inputs", ctx.Inputs).
+		Msg("Starting poll node execution")
+
+	// The request sent on every poll; assertions and outputs only apply to the last response
+	request := &RequestNode{
+		BaseNode: BaseNode{ID: n.GetID(), DisplayName: n.GetDisplayName(), NodeType: TypePoll},
+		Data:     n.Data.Request,
+	}
+	result := &PollExecutionResult{}
+
+	err := n.Data.validate()
+	if err == nil {
+		err = request.validateInputsPresent(ctx)
+	}
+	if err != nil {
+		return n.failResult(result, request, ctx.Inputs, err, startTime), err
+	}
+	prepared, body, err := request.buildRequest(ctx)
+	if err != nil {
+		return n.failResult(result, request, ctx.Inputs, err, startTime), err
+	}
+
+	outcome, met, err := n.poll(ctx, request, prepared, body, result)
+	if err != nil {
+		loggerOf(ctx).Error().
+			Str("nodeID", n.GetID()).
+			Int("polls", len(result.Polls)).
+			Err(err).
+			Msg("Poll node failed")
+		return n.failResult(result, request, ctx.Inputs, err, startTime), err
+	}
+
+	result.BaseExecutionResult = BaseExecutionResult{
+		NodeID:      n.GetID(),
+		DisplayName: n.GetDisplayName(),
+		NodeType:    TypePoll,
+		Inputs:      ctx.Inputs,
+	}
+	result.RequestMethod = n.Data.Request.Method
+	result.RequestURL = prepared.url
+	result.RequestHeaders = recordedHeaders(outcome.headers, prepared.auth, ctx.Redactor)
+	result.RequestBody = prepared.body
+	result.ResponseStatusCode = outcome.resp.StatusCode
+	result.ResponseHeaders = outcome.resp.Header
+	result.ResponseBody = outcome.respBody
+	result.ResponseBodyParsed = outcome.parsedBody
+
+	if !met {
+		err = fmt.Errorf(
+			"stop condition not met after %d poll(s) in %d ms", len(result.Polls), time.Since(startTime).Milliseconds(),
+		)
+		loggerOf(ctx).Error().
+			Str("nodeID", n.GetID()).
+			Err(err).
+			Msg("Poll node gave up")
+		return n.markFailed(result, err, ErrorCodePollTimeout, startTime), err
+	}
+
+	outputs, errCode, err := n.checkResponse(outcome, ctx, result)
+	if err != nil {
+		return n.markFailed(result, err, errCode, startTime), err
+	}
+	result.Outputs = outputs
+	result.ExecutedAt = time.Now()
+	result.DurationMs = time.Since(startTime).Milliseconds()
+
+	loggerOf(ctx).Info().
+		Str("nodeID", n.GetID()).
+		Int("polls", len(result.Polls)).
+		Int("statusCode", outcome.resp.StatusCode).
+		Int64("durationMs", result.DurationMs).
+		Msg("Poll node executed successfully")
+
+	return result, nil
+}
+
+// poll sends the request until the stop condition holds, the timeout elapses or the maximum
+// number of polls is reached. It returns the outcome of the last poll and whether the condition held.
+// A request that fails despite its retry policy fails the node.
+func (n *PollNode) poll(
+	ctx ExecutionContext, request *RequestNode, prepared *preparedRequest, body *requestBody,
+	result *PollExecutionResult,
+) (*attemptOutcome, bool, error) {
+	runCtx := contextOf(ctx)
+	startTime := time.Now()
+	for pollNum := 1; ; pollNum++ {
+		pollStart := time.Now()
+		outcome, attempts, err := request.performAttempts(ctx, prepared, body)
+		record := PollAttempt{
+			Attempt:    pollNum,
+			StartedAt:  pollStart,
+			DurationMs: time.Since(pollStart).Milliseconds(),
+		}
+		result.Attempts = attempts
+		if err != nil {
+			errMsg := err.Error()
+			record.Error = &errMsg
+			result.Polls = append(result.Polls, record)
+			return nil, false, err
+		}
+
+		record.StatusCode = outcome.resp.StatusCode
+		record.Conditions, record.ConditionMet = n.evaluateStopCondition(outcome.respCtx)
+		delay := n.Data.interval(pollNum)
+		if record.ConditionMet || n.Data.exhausted(pollNum, time.Since(startTime)+delay) {
+			result.Polls = append(result.Polls, record)
+			return outcome, record.ConditionMet, nil
+		}
+		record.DelayMs = delay.Milliseconds()
+		result.Polls = append(result.Polls, record)
+
+		loggerOf(ctx).Debug().
+			Str("nodeID", n.GetID()).
+			Int("poll", pollNum).
+			Int("statusCode", record.StatusCode).
+			Int64("delayMs", record.DelayMs).
+			Msg("Stop condition not met, polling again")
+
+		if sleepErr := sleepContext(runCtx, delay); sleepErr != nil {
+			return outcome, false, sleepErr
+		}
+	}
+}
+
+// evaluateStopCondition reports whether every assertion of the stop condition passes.
+func (n *PollNode) evaluateStopCondition(respCtx extractors.ResponseContext) ([]AssertionResult, bool) {
+	results := make([]AssertionResult, 0, len(n.Data.Until))
+	met := true
+	for i, condition := range n.Data.Until {
+		outcome := condition.Evaluate(i, respCtx)
+		results = append(results, outcome)
+		met = met && outcome.Passed
+	}
+	return results, met
+}
+
+// checkResponse runs the assertions of the node on the last response and extracts the outputs.
+// On failure it returns the error code of the result.
+func (n *PollNode) checkResponse(
+	outcome *attemptOutcome, ctx ExecutionContext, result *PollExecutionResult,
+) (map[string]interface{}, ErrorCode, error) {
+	checker := &RequestNode{BaseNode: n.BaseNode, Data: n.Data.Request}
+
+	assertionResults, err := checker.runAssertions(ctx, outcome.respCtx)
+	result.AssertionResults = assertionResults
+	if err != nil {
+		return nil, ErrorCodeAssertionFailed, err
+	}
+
+	outputs, err := checker.extractOutputs(ctx, outcome.respCtx)
+	if err == nil {
+		err = checker.validateOutput(ctx, outputs)
+	}
+	if err != nil {
+		return nil, ErrorCodeExtractionFailed, err
+	}
+
+	headers := make(map[string]interface{}, len(outcome.resp.Header))
+	for key, values := range outcome.resp.Header {
+		headers[key] = strings.Join(values, ", ")
+	}
+	outputs[pollResponseOutput] = map[string]interface{}{
+		"statusCode": outcome.resp.StatusCode,
+		"headers":    headers,
+		"body":       outcome.parsedBody,
+	}
+	outputs[pollAttemptsOutput] = len(result.Polls)
+	return outputs, "", nil
+}
+
+// failResult creates the result of a poll node that failed before receiving a usable response.
+func (n *PollNode) failResult(
+	result *PollExecutionResult, request *RequestNode, inputs map[string]interface{}, err error,
+	startTime time.Time,
+) *PollExecutionResult {
+	attempts := result.Attempts
+	result.RequestExecutionResult = *request.createErrorResult(inputs, err, time.Since(startTime))
+	result.NodeType = TypePoll
+	result.Attempts = attempts
+	return result
+}
+
+// markFailed marks a result that already holds the last response as failed.
+func (n *PollNode) markFailed(
+	result *PollExecutionResult, err error, errCode ErrorCode, startTime time.Time,
+) *PollExecutionResult {
+	errMsg := err.Error()
+	result.Error = err
+	result.ErrorMsg = &errMsg
+	result.ErrorCode = &errCode
+	result.ExecutedAt = time.Now()
+	result.DurationMs = time.Since(startTime).Milliseconds()
+	return result
+}
+
+func (d PollData) validate() error {
+	if len(d.Until) == 0 {
+		return errors.New("poll node needs a stop condition")
+	}
+	if d.TimeoutMs <= 0 && d.MaxAttempts <= 0 {
+		return errors.New("poll node needs a timeout or a maximum number of attempts")
+	}
+	return nil
+}
+
+// interval returns the wait after the given (1-based) poll.
+func (d PollData) interval(poll int) time.Duration {
+	backoff := BackoffPolicy{Type: BackoffFixed, InitialDelayMs: d.IntervalMs}
+	if d.Backoff != nil {
+		backoff.Type = BackoffExponential
+		backoff.Multiplier = d.Backoff.Multiplier
+		backoff.MaxDelayMs = d.Backoff.MaxIntervalMs
+		backoff.Jitter = d.Backoff.Jitter
+	}
+	return backoff.delay(poll)
+}
+
+// exhausted reports whether no poll may follow the given poll, which would start after elapsed.
+func (d PollData) exhausted(poll int, elapsed time.Duration) bool {
+	if d.MaxAttempts > 0 && poll >= d.MaxAttempts {
+		return true
+	}
+	return d.TimeoutMs > 0 && elapsed > time.Duration(d.TimeoutMs)*time.Millisecond
+}
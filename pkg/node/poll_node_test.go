@@ -0,0 +1,182 @@
+package node_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/node"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/redact"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newJobServer reports a pending job for the first pending requests, then a done job.
+func newJobServer(t *testing.T, pending int32) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				call := calls.Add(1)
+				if call <= pending {
+					_, _ = w.Write([]byte(`{"id": "` + r.URL.Path[len("/jobs/"):] + `", "status": "pending"}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"status": "done", "result": {"rows": ` + strconv.Itoa(int(call)) + `}}`))
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func unmarshalPollNode(t *testing.T, baseURL, options string) *node.PollNode {
+	t.Helper()
+	parsed, err := node.UnmarshalNode(
+		[]byte(`{
+		"id": "wait-job",
+		"type": "poll",
+		"data": {
+			"request": {"method": "GET", "url": "` + baseURL + `/jobs/{{create-job.id}}"},
+			"until": [
+				{"extractor": {"type": "jsonPath", "path": "$.status"}, "operator": {"type": "equals", "expected": "done"}}
+			],
+			` + options + `
+		},
+		"outputs": [{"name": "rows", "extractor": {"type": "jsonPath", "path": "$.result.rows"}}]
+	}`),
+	)
+	require.NoError(t, err)
+	return node.MustAsPollNode(parsed)
+}
+
+func TestPollNode_Execute(t *testing.T) {
+	server, calls := newJobServer(t, 2)
+	pollNode := unmarshalPollNode(
+		t, server.URL, `"intervalMs": 5, "backoff": {"multiplier": 2}, "timeoutMs": 5000`,
+	)
+	assert.Equal(t, []string{"create-job.id"}, pollNode.InputSchema())
+	assert.Equal(t, []string{"rows", "response", "attempts"}, pollNode.OutputSchema())
+
+	result, err := pollNode.Execute(
+		node.ExecutionContext{Inputs: map[string]interface{}{"create-job.id": "job-1"}},
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+	outputs := result.GetOutputs()
+	assert.InDelta(t, 3.0, outputs["rows"], 0)
+	assert.Equal(t, 3, outputs["attempts"])
+	response, ok := outputs["response"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, http.StatusOK, response["statusCode"])
+	assert.Equal(t, "application/json", response["headers"].(map[string]interface{})["Content-Type"])
+	assert.Equal(t, "done", response["body"].(map[string]interface{})["status"])
+
+	pollResult := node.MustAsPollExecutionResult(result)
+	assert.Equal(t, node.TypePoll, pollResult.NodeType)
+	require.Len(t, pollResult.Polls, 3)
+	assert.Equal(t, int64(5), pollResult.Polls[0].DelayMs)
+	assert.Equal(t, int64(10), pollResult.Polls[1].DelayMs)
+	assert.False(t, pollResult.Polls[1].ConditionMet)
+	assert.Equal(t, "pending", pollResult.Polls[1].Conditions[0].Actual)
+	assert.True(t, pollResult.Polls[2].ConditionMet)
+	assert.Equal(t, int64(0), pollResult.Polls[2].DelayMs)
+}
+
+func TestPollNode_Execute_RenewsCredentials(t *testing.T) {
+	tokenServer := newExpiringTokenServer(t)
+	var authorizations []string
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				authorizations = append(authorizations, r.Header.Get("Authorization"))
+				w.Header().Set("Content-Type", "application/json")
+				if len(authorizations) < 3 {
+					_, _ = w.Write([]byte(`{"status": "pending"}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"status": "done"}`))
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+	parsed, err := node.UnmarshalNode(
+		[]byte(`{
+		"id": "wait-job",
+		"type": "poll",
+		"data": {
+			"request": {
+				"method": "GET",
+				"url": "` + server.URL + `/jobs/1",
+				"auth": {"type": "oauth2ClientCredentials", "tokenUrl": "` + tokenServer.URL + `", "clientId": "my-client"}
+			},
+			"until": [
+				{"extractor": {"type": "jsonPath", "path": "$.status"}, "operator": {"type": "equals", "expected": "done"}}
+			],
+			"intervalMs": 1,
+			"maxAttempts": 5
+		}
+	}`),
+	)
+	require.NoError(t, err)
+
+	result, err := node.MustAsPollNode(parsed).Execute(
+		node.ExecutionContext{Inputs: map[string]interface{}{}, TokenCache: node.NewTokenCache()},
+	)
+
+	require.NoError(t, err)
+	assert.Equal(
+		t, []string{"Bearer access-1", "Bearer access-2", "Bearer access-3"}, authorizations,
+		"every poll should send a token that is still valid",
+	)
+	assert.Equal(t, redact.Mask, node.MustAsPollExecutionResult(result).RequestHeaders["Authorization"])
+}
+
+func TestPollNode_Execute_GivesUp(t *testing.T) {
+	tests := []struct {
+		name          string
+		options       string
+		expectedPolls int
+	}{
+		{name: "max attempts", options: `"intervalMs": 1, "maxAttempts": 3`, expectedPolls: 3},
+		{name: "timeout", options: `"intervalMs": 40, "timeoutMs": 100`, expectedPolls: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				server, _ := newJobServer(t, 100)
+				pollNode := unmarshalPollNode(t, server.URL, tt.options)
+
+				result, err := pollNode.Execute(
+					node.ExecutionContext{Inputs: map[string]interface{}{"create-job.id": "job-1"}},
+				)
+
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "stop condition not met after "+strconv.Itoa(tt.expectedPolls)+" poll(s)")
+				pollResult := node.MustAsPollExecutionResult(result)
+				assert.Equal(t, node.ErrorCodePollTimeout, *pollResult.ErrorCode)
+				assert.Len(t, pollResult.Polls, tt.expectedPolls)
+				assert.Equal(t, http.StatusOK, pollResult.ResponseStatusCode)
+				assert.Nil(t, pollResult.Outputs)
+			},
+		)
+	}
+}
+
+func TestPollNode_Execute_InvalidConfiguration(t *testing.T) {
+	pollNode := unmarshalPollNode(t, "http://localhost", `"intervalMs": 10`)
+
+	result, err := pollNode.Execute(
+		node.ExecutionContext{Inputs: map[string]interface{}{"create-job.id": "job-1"}},
+	)
+
+	require.Error(t, err)
+	assert.Equal(t, "poll node needs a timeout or a maximum number of attempts", err.Error())
+	assert.Equal(t, node.ErrorCodeRequestFailed, *node.MustAsPollExecutionResult(result).ErrorCode)
+}
@@ -0,0 +1,114 @@
+package node
+
+import (
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/redact"
+)
+
+// Redact masks the secrets known to redactor in every input, output, request and response
+// recorded in the result. Results are redacted in place once the run is over, so that nodes
+// still received the real values.
+func (r *FlowExecutionResult) Redact(redactor *redact.Redactor) {
+	if redactor.Empty() {
+		return
+	}
+	for _, result := range r.ExecutionResults {
+		result.redact(redactor)
+	}
+	r.Outputs = redactor.Map(r.Outputs)
+	r.FinalOutputs = redactor.Map(r.FinalOutputs)
+	r.ErrorMsg = redactString(redactor, r.ErrorMsg)
+}
+
+func (b *BaseExecutionResult) redact(redactor *redact.Redactor) {
+	b.Inputs = redactor.Map(b.Inputs)
+	b.Outputs = redactor.Map(b.Outputs)
+	b.ErrorMsg = redactString(redactor, b.ErrorMsg)
+}
+
+func (r *RequestExecutionResult) redact(redactor *redact.Redactor) {
+	r.BaseExecutionResult.redact(redactor)
+	r.RequestURL = redactor.String(r.RequestURL)
+	r.RequestHeaders = redactor.StringMap(r.RequestHeaders)
+	r.RequestBody = redactor.Value(r.RequestBody)
+	r.ResponseBody = redactor.Bytes(r.ResponseBody)
+	r.ResponseBodyParsed = redactor.Value(r.ResponseBodyParsed)
+	for key, values := range r.ResponseHeaders {
+		redacted := make([]string, len(values))
+		for i, value := range values {
+			redacted[i] = redactor.String(value)
+		}
+		r.ResponseHeaders[key] = redacted
+	}
+	for i := range r.AssertionResults {
+		r.AssertionResults[i].Actual = redactor.Value(r.AssertionResults[i].Actual)
+		r.AssertionResults[i].Message = redactor.String(r.AssertionResults[i].Message)
+	}
+	for i := range r.Attempts {
+		r.Attempts[i].Error = redactString(redactor, r.Attempts[i].Error)
+	}
+}
+
+func (r *ForEachExecutionResult) redact(redactor *redact.Redactor) {
+	r.BaseExecutionResult.redact(redactor)
+	for i := range r.Iterations {
+		r.Iterations[i].Item = redactor.Value(r.Iterations[i].Item)
+		if r.Iterations[i].Result != nil {
+			r.Iterations[i].Result.Redact(redactor)
+		}
+	}
+}
+
+func (r *ConditionExecutionResult) redact(redactor *redact.Redactor) {
+	r.BaseExecutionResult.redact(redactor)
+	for i := range r.Evaluations {
+		for j := range r.Evaluations[i].Conditions {
+			condition := &r.Evaluations[i].Conditions[j]
+			condition.Actual = redactor.Value(condition.Actual)
+			condition.Message = redactor.String(condition.Message)
+		}
+	}
+}
+
+func (r *SubflowExecutionResult) redact(redactor *redact.Redactor) {
+	r.BaseExecutionResult.redact(redactor)
+	if r.Result != nil {
+		r.Result.Redact(redactor)
+	}
+}
+
+func (r *PollExecutionResult) redact(redactor *redact.Redactor) {
+	r.RequestExecutionResult.redact(redactor)
+	for i := range r.Polls {
+		r.Polls[i].Error = redactString(redactor, r.Polls[i].Error)
+		for j := range r.Polls[i].Conditions {
+			condition := &r.Polls[i].Conditions[j]
+			condition.Actual = redactor.Value(condition.Actual)
+			condition.Message = redactor.String(condition.Message)
+		}
+	}
+}
+
+func redactString(redactor *redact.Redactor, s *string) *string {
+	if s == nil {
+		return nil
+	}
+	redacted := redactor.String(*s)
+	return &redacted
+}
+
+// recordedHeaders returns the request headers as recorded in the result: headers carrying
+// credentials set by the auth block are masked, and known secrets are masked in the others.
+func recordedHeaders(headers map[string]string, auth *Auth, redactor *redact.Redactor) map[string]string {
+	recorded := make(map[string]string, len(headers))
+	for key, value := range headers {
+		recorded[key] = redactor.String(value)
+	}
+	if auth != nil {
+		for _, name := range auth.credentialHeaders() {
+			if _, exists := recorded[name]; exists {
+				recorded[name] = redact.Mask
+			}
+		}
+	}
+	return recorded
+}
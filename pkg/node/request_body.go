@@ -0,0 +1,221 @@
+package node
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// BodyType selects how RequestData.Body is encoded on the wire.
+type BodyType string
+
+const (
+	// BodyTypeJSON marshals the body as JSON. It is the default.
+	BodyTypeJSON BodyType = "json"
+	// BodyTypeFormURLEncoded expects an object whose values are scalars or arrays of scalars;
+	// arrays become repeated keys. A string body is sent as-is.
+	BodyTypeFormURLEncoded BodyType = "form-urlencoded"
+	// BodyTypeMultipart expects an object mapping part names to values. Scalars become form fields,
+	// objects with "content" or "contentBase64" become file parts (with optional "filename" and
+	// "contentType"), and arrays repeat the part.
+	BodyTypeMultipart BodyType = "multipart"
+	// BodyTypeRaw sends the body as text.
+	BodyTypeRaw BodyType = "raw"
+	// BodyTypeBinary expects the body as a base64 encoded string and sends the decoded bytes.
+	BodyTypeBinary BodyType = "binary"
+)
+
+const (
+	contentTypeHeader       = "Content-Type"
+	multipartFormDataType   = "multipart/form-data"
+	defaultFilePartMimeType = "application/octet-stream"
+)
+
+// requestBody is a request body encoded once and sent on every attempt.
+type requestBody struct {
+	payload     []byte
+	contentType string
+}
+
+// encodeRequestBody encodes a resolved body according to its type. A Content-Type supplied in
+// headers wins over the default of the body type; for multipart bodies the boundary is added to
+// it when missing. A nil body sends no body.
+func encodeRequestBody(bodyType BodyType, body interface{}, headers map[string]string) (*requestBody, error) {
+	if body == nil {
+		return nil, nil //nolint:nilnil // a nil body means the request has no body
+	}
+
+	var encoded *requestBody
+	var err error
+	switch bodyType {
+	case BodyTypeJSON, "":
+		encoded, err = encodeJSONBody(body)
+	case BodyTypeFormURLEncoded:
+		encoded, err = encodeFormBody(body)
+	case BodyTypeMultipart:
+		encoded, err = encodeMultipartBody(body)
+	case BodyTypeRaw:
+		encoded = &requestBody{
+			payload:     []byte(formatTemplateValue(body)),
+			contentType: "text/plain; charset=utf-8",
+		}
+	case BodyTypeBinary:
+		encoded, err = encodeBinaryBody(body)
+	default:
+		return nil, fmt.Errorf("unsupported body type '%s'", bodyType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s body: %w", bodyType, err)
+	}
+
+	if userContentType, ok := headerValue(headers, contentTypeHeader); ok {
+		encoded.contentType = withMultipartBoundary(userContentType, encoded.contentType)
+	}
+	return encoded, nil
+}
+
+func encodeJSONBody(body interface{}) (*requestBody, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return &requestBody{payload: payload, contentType: "application/json"}, nil
+}
+
+func encodeFormBody(body interface{}) (*requestBody, error) {
+	const contentType = "application/x-www-form-urlencoded"
+	if raw, isString := body.(string); isString {
+		return &requestBody{payload: []byte(raw), contentType: contentType}, nil
+	}
+	fields, isMap := body.(map[string]interface{})
+	if !isMap {
+		return nil, fmt.Errorf("expected an object or a string, got %T", body)
+	}
+
+	values := url.Values{}
+	for key, value := range fields {
+		addValues(values, key, value)
+	}
+	// Encode sorts by key, which keeps the payload deterministic
+	return &requestBody{payload: []byte(values.Encode()), contentType: contentType}, nil
+}
+
+func encodeMultipartBody(body interface{}) (*requestBody, error) {
+	parts, isMap := body.(map[string]interface{})
+	if !isMap {
+		return nil, fmt.Errorf("expected an object, got %T", body)
+	}
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	names := make([]string, 0, len(parts))
+	for name := range parts {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		values, isList := parts[name].([]interface{})
+		if !isList {
+			values = []interface{}{parts[name]}
+		}
+		for _, value := range values {
+			if err := writeMultipartPart(writer, name, value); err != nil {
+				return nil, fmt.Errorf("part '%s': %w", name, err)
+			}
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return &requestBody{payload: buf.Bytes(), contentType: writer.FormDataContentType()}, nil
+}
+
+func writeMultipartPart(writer *multipart.Writer, name string, value interface{}) error {
+	file, isFile := value.(map[string]interface{})
+	if !isFile {
+		return writer.WriteField(name, formatTemplateValue(value))
+	}
+
+	content, err := filePartContent(file)
+	if err != nil {
+		return err
+	}
+	header := make(textproto.MIMEHeader)
+	disposition := map[string]string{"name": name}
+	if filename, hasName := file["filename"]; hasName {
+		disposition["filename"] = formatTemplateValue(filename)
+	}
+	header.Set("Content-Disposition", mime.FormatMediaType("form-data", disposition))
+	header.Set(contentTypeHeader, defaultFilePartMimeType)
+	if partType, hasType := file["contentType"]; hasType {
+		header.Set(contentTypeHeader, formatTemplateValue(partType))
+	}
+
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(content)
+	return err
+}
+
+// filePartContent returns the content of a multipart file part, given inline or base64 encoded.
+func filePartContent(file map[string]interface{}) ([]byte, error) {
+	if encoded, isBase64 := file["contentBase64"]; isBase64 {
+		content, err := base64.StdEncoding.DecodeString(formatTemplateValue(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("invalid contentBase64: %w", err)
+		}
+		return content, nil
+	}
+	if content, isInline := file["content"]; isInline {
+		return []byte(formatTemplateValue(content)), nil
+	}
+	return nil, errors.New("file parts must define 'content' or 'contentBase64'")
+}
+
+func encodeBinaryBody(body interface{}) (*requestBody, error) {
+	encoded, isString := body.(string)
+	if !isString {
+		return nil, fmt.Errorf("expected a base64 encoded string, got %T", body)
+	}
+	payload, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 content: %w", err)
+	}
+	return &requestBody{payload: payload, contentType: defaultFilePartMimeType}, nil
+}
+
+// withMultipartBoundary keeps a user supplied content type, adding the boundary of the encoded
+// multipart body when the user declared multipart/form-data without one.
+func withMultipartBoundary(userContentType, encodedContentType string) string {
+	mediaType, params, err := mime.ParseMediaType(userContentType)
+	if err != nil || !strings.EqualFold(mediaType, multipartFormDataType) || params["boundary"] != "" {
+		return userContentType
+	}
+	_, encodedParams, err := mime.ParseMediaType(encodedContentType)
+	if err != nil || encodedParams["boundary"] == "" {
+		return userContentType
+	}
+	params["boundary"] = encodedParams["boundary"]
+	return mime.FormatMediaType(mediaType, params)
+}
+
+// headerValue looks up a header case-insensitively, as HTTP header names are.
+func headerValue(headers map[string]string, name string) (string, bool) {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
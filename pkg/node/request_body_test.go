@@ -0,0 +1,181 @@
+package node_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/node"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// capturedRequest is what a capturing server received.
+type capturedRequest struct {
+	contentType string
+	body        []byte
+	form        map[string][]string
+	files       map[string]capturedFile
+}
+
+type capturedFile struct {
+	filename    string
+	contentType string
+	content     string
+}
+
+// newCapturingServer returns a test server that records the body of the last request.
+func newCapturingServer(t *testing.T, captured *capturedRequest) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				captured.contentType = r.Header.Get("Content-Type")
+				if strings.HasPrefix(captured.contentType, "multipart/") {
+					require.NoError(t, r.ParseMultipartForm(1<<20))
+					captured.form = r.MultipartForm.Value
+					captured.files = make(map[string]capturedFile)
+					for name, headers := range r.MultipartForm.File {
+						file, _ := headers[0].Open()
+						content, _ := io.ReadAll(file)
+						captured.files[name] = capturedFile{
+							filename:    headers[0].Filename,
+							contentType: headers[0].Header.Get("Content-Type"),
+							content:     string(content),
+						}
+					}
+				} else {
+					captured.body, _ = io.ReadAll(r.Body)
+				}
+				w.WriteHeader(http.StatusNoContent)
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRequestNode_Execute_FormURLEncodedBody(t *testing.T) {
+	var captured capturedRequest
+	server := newCapturingServer(t, &captured)
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "token",
+		"type": "request",
+		"data": {
+			"method": "POST",
+			"url": "`+server.URL+`",
+			"bodyType": "form-urlencoded",
+			"body": {
+				"grant_type": "client_credentials",
+				"client_id": "{{clientId}}",
+				"scope": ["read", "write"]
+			}
+		}
+	}`,
+	)
+
+	_, err := reqNode.Execute(
+		node.ExecutionContext{Inputs: map[string]interface{}{"clientId": "my client"}},
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, "application/x-www-form-urlencoded", captured.contentType)
+	assert.Equal(t, "client_id=my+client&grant_type=client_credentials&scope=read&scope=write", string(captured.body))
+}
+
+func TestRequestNode_Execute_MultipartBody(t *testing.T) {
+	var captured capturedRequest
+	server := newCapturingServer(t, &captured)
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "upload",
+		"type": "request",
+		"data": {
+			"method": "POST",
+			"url": "`+server.URL+`",
+			"bodyType": "multipart",
+			"body": {
+				"description": "avatar of {{userId}}",
+				"tags": ["a", "b"],
+				"avatar": {"filename": "avatar.png", "contentType": "image/png", "contentBase64": "iVBORw=="},
+				"notes": {"filename": "{{userId}}.txt", "content": "hello {{userId}}"}
+			}
+		}
+	}`,
+	)
+
+	_, err := reqNode.Execute(node.ExecutionContext{Inputs: map[string]interface{}{"userId": "42"}})
+
+	require.NoError(t, err)
+	assert.Contains(t, captured.contentType, "multipart/form-data; boundary=")
+	assert.Equal(
+		t, map[string][]string{"description": {"avatar of 42"}, "tags": {"a", "b"}}, captured.form,
+	)
+	assert.Equal(
+		t, map[string]capturedFile{
+			"avatar": {filename: "avatar.png", contentType: "image/png", content: "\x89PNG"},
+			"notes":  {filename: "42.txt", contentType: "application/octet-stream", content: "hello 42"},
+		}, captured.files,
+	)
+}
+
+func TestRequestNode_Execute_RawBodyKeepsUserContentType(t *testing.T) {
+	var captured capturedRequest
+	server := newCapturingServer(t, &captured)
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "webhook",
+		"type": "request",
+		"data": {
+			"method": "POST",
+			"url": "`+server.URL+`",
+			"headers": {"content-type": "text/csv"},
+			"bodyType": "raw",
+			"body": "id,name\n{{userId}},Alice"
+		}
+	}`,
+	)
+
+	_, err := reqNode.Execute(node.ExecutionContext{Inputs: map[string]interface{}{"userId": "42"}})
+
+	require.NoError(t, err)
+	assert.Equal(t, "text/csv", captured.contentType)
+	assert.Equal(t, "id,name\n42,Alice", string(captured.body))
+}
+
+func TestRequestNode_Execute_BinaryBody(t *testing.T) {
+	var captured capturedRequest
+	server := newCapturingServer(t, &captured)
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "upload",
+		"type": "request",
+		"data": {"method": "PUT", "url": "`+server.URL+`", "bodyType": "binary", "body": "{{payload}}"}
+	}`,
+	)
+
+	_, err := reqNode.Execute(node.ExecutionContext{Inputs: map[string]interface{}{"payload": "AAEC/w=="}})
+
+	require.NoError(t, err)
+	assert.Equal(t, "application/octet-stream", captured.contentType)
+	assert.Equal(t, []byte{0x00, 0x01, 0x02, 0xff}, captured.body)
+}
+
+func TestRequestNode_Execute_InvalidBody(t *testing.T) {
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "upload",
+		"type": "request",
+		"data": {"method": "PUT", "url": "http://localhost", "bodyType": "binary", "body": "not base64!"}
+	}`,
+	)
+
+	result, err := reqNode.Execute(node.ExecutionContext{Inputs: map[string]interface{}{}})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to encode binary body")
+	assert.Equal(t, node.ErrorCodeRequestFailed, *node.MustAsRequestExecutionResult(result).ErrorCode)
+}
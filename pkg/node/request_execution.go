@@ -5,16 +5,14 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/rs/zerolog/log"
-
 	"github.com/nanostack-dev/echopoint-flow-engine/pkg/extractors"
 )
 
-func (n *RequestNode) validateInputsPresent(inputs map[string]interface{}) error {
+func (n *RequestNode) validateInputsPresent(ctx ExecutionContext) error {
 	for _, dep := range n.InputSchema() {
-		if _, exists := inputs[dep]; !exists {
+		if _, exists := ctx.Inputs[dep]; !exists {
 			err := fmt.Errorf("missing required input: %s", dep)
-			log.Error().
+			loggerOf(ctx).Error().
 				Str("nodeID", n.GetID()).
 				Str("missingInput", dep).
 				Err(err).
@@ -25,23 +23,32 @@ func (n *RequestNode) validateInputsPresent(inputs map[string]interface{}) error
 	return nil
 }
 
-func (n *RequestNode) prepareRequest(inputs map[string]interface{}) (string, map[string]string, interface{}, error) {
-	log.Debug().
+// preparedRequest is a request whose templates are resolved.
+type preparedRequest struct {
+	url     string
+	headers map[string]string
+	body    interface{}
+	auth    *Auth
+}
+
+func (n *RequestNode) prepareRequest(ctx ExecutionContext) (*preparedRequest, error) {
+	loggerOf(ctx).Debug().
 		Str("nodeID", n.GetID()).
 		Str("rawURL", n.Data.URL).
 		Msg("Resolving URL templates")
 
-	url, err := n.resolveTemplatesWithError(n.Data.URL, inputs)
+	resolver := newTemplateResolver(ctx)
+	url, err := resolver.ResolveStringAt(n.Data.URL, "url")
 	if err != nil {
 		err = fmt.Errorf("failed to resolve URL templates: %w", err)
-		log.Error().
+		loggerOf(ctx).Error().
 			Str("nodeID", n.GetID()).
 			Err(err).
 			Msg("URL template resolution failed")
-		return "", nil, nil, err
+		return nil, err
 	}
 
-	log.Debug().
+	loggerOf(ctx).Debug().
 		Str("nodeID", n.GetID()).
 		Str("resolvedURL", url).
 		Msg("URL templates resolved successfully")
@@ -49,43 +56,81 @@ func (n *RequestNode) prepareRequest(inputs map[string]interface{}) (string, map
 	// Resolve headers
 	headers := make(map[string]string)
 	for k, v := range n.Data.Headers {
-		resolved := n.resolveTemplates(v, inputs)
-		if s, ok := resolved.(string); ok {
-			headers[k] = s
-		} else {
-			headers[k] = fmt.Sprintf("%v", resolved)
+		resolved, headerErr := resolver.ResolveStringAt(v, "header:"+k)
+		if headerErr != nil {
+			return nil, fmt.Errorf("failed to resolve header '%s' templates: %w", k, headerErr)
 		}
+		headers[k] = resolved
 	}
 
-	body := n.resolveTemplates(n.Data.Body, inputs)
+	body, err := resolver.ResolveAt(n.Data.Body, "body")
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve body templates: %w", err)
+	}
+
+	pathParams, err := resolveParams(resolver, n.Data.PathParams, "path:")
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path parameter templates: %w", err)
+	}
+	queryParams, err := resolveParams(resolver, n.Data.QueryParams, "query:")
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve query parameter templates: %w", err)
+	}
 
-	// Resolve query parameters and append to URL
-	if len(n.Data.QueryParams) > 0 {
-		var queryString []string
-		for k, v := range n.Data.QueryParams {
-			resolvedK := n.resolveTemplates(k, inputs)
-			resolvedV := n.resolveTemplates(v, inputs)
-			queryString = append(queryString, fmt.Sprintf("%v=%v", resolvedK, resolvedV))
+	var auth *Auth
+	if n.Data.Auth != nil {
+		if auth, err = n.Data.Auth.resolve(resolver); err != nil {
+			return nil, err
 		}
-		if strings.Contains(url, "?") {
-			url += "&" + strings.Join(queryString, "&")
-		} else {
-			url += "?" + strings.Join(queryString, "&")
+		auth.registerSecrets(ctx.Redactor)
+	}
+
+	if unresolvedErr := checkUnresolvedTemplates(n.GetID(), resolver, ctx); unresolvedErr != nil {
+		return nil, unresolvedErr
+	}
+
+	if auth != nil {
+		if err = auth.applyStatic(headers, queryParams); err != nil {
+			return nil, err
 		}
 	}
 
-	log.Debug().
+	url, err = buildRequestURL(url, pathParams, queryParams)
+	if err != nil {
+		return nil, err
+	}
+
+	loggerOf(ctx).Debug().
 		Str("nodeID", n.GetID()).
 		Str("method", n.Data.Method).
 		Str("url", url).
 		Int("timeout", n.Data.Timeout).
 		Msg("Making HTTP request")
 
-	return url, headers, body, nil
+	return &preparedRequest{url: url, headers: headers, body: body, auth: auth}, nil
 }
 
-func (n *RequestNode) parseResponseBody(contentType string, respBody []byte) interface{} {
-	log.Debug().
+// resolveParams resolves the templates in the keys and values of path or query parameters.
+func resolveParams(
+	resolver *TemplateResolver, params map[string]interface{}, locationPrefix string,
+) (map[string]interface{}, error) {
+	resolved := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		key, err := resolver.ResolveStringAt(k, locationPrefix+k)
+		if err != nil {
+			return nil, err
+		}
+		value, err := resolver.ResolveAt(v, locationPrefix+k)
+		if err != nil {
+			return nil, fmt.Errorf("parameter '%s': %w", k, err)
+		}
+		resolved[key] = value
+	}
+	return resolved, nil
+}
+
+func (n *RequestNode) parseResponseBody(ctx ExecutionContext, contentType string, respBody []byte) interface{} {
+	loggerOf(ctx).Debug().
 		Str("nodeID", n.GetID()).
 		Str("contentType", contentType).
 		Msg("Parsing response body")
@@ -93,7 +138,7 @@ func (n *RequestNode) parseResponseBody(contentType string, respBody []byte) int
 	if strings.Contains(contentType, "application/json") {
 		var parsedBody interface{}
 		if unmarshalErr := json.Unmarshal(respBody, &parsedBody); unmarshalErr != nil {
-			log.Warn().
+			loggerOf(ctx).Warn().
 				Str("nodeID", n.GetID()).
 				Err(unmarshalErr).
 				Msg("JSON parsing failed, treating body as string")
@@ -101,44 +146,61 @@ func (n *RequestNode) parseResponseBody(contentType string, respBody []byte) int
 		}
 		return parsedBody
 	}
+
+	// Other bodies, XML included, are kept as text. XPath extractors share the XML document
+	// that the response context parses on first use.
 	return string(respBody)
 }
 
-func (n *RequestNode) runAssertions(respCtx extractors.ResponseContext) error {
-	log.Debug().
+func (n *RequestNode) runAssertions(
+	ctx ExecutionContext, respCtx extractors.ResponseContext,
+) ([]AssertionResult, error) {
+	loggerOf(ctx).Debug().
 		Str("nodeID", n.GetID()).
 		Int("assertionCount", len(n.GetAssertions())).
 		Msg("Running assertions")
 
+	results := make([]AssertionResult, 0, len(n.GetAssertions()))
+	var failures []AssertionResult
 	for i, assertion := range n.GetAssertions() {
-		if !n.validate(assertion, respCtx) {
-			failedAssertionErr := fmt.Errorf("assertion failed: %v", assertion)
-			log.Error().
+		result := assertion.Evaluate(i, respCtx)
+		results = append(results, result)
+		if !result.Passed {
+			loggerOf(ctx).Error().
 				Str("nodeID", n.GetID()).
 				Int("assertionIndex", i).
-				Err(failedAssertionErr).
+				Str("extractorType", string(result.ExtractorType)).
+				Str("operatorType", string(result.OperatorType)).
+				Any("actual", result.Actual).
+				Str("message", result.Message).
 				Msg("Assertion validation failed")
-			return failedAssertionErr
+			failures = append(failures, result)
 		}
 	}
 
-	log.Debug().
+	if len(failures) > 0 {
+		return results, &AssertionError{NodeID: n.GetID(), Failures: failures}
+	}
+
+	loggerOf(ctx).Debug().
 		Str("nodeID", n.GetID()).
 		Msg("All assertions passed")
 
-	return nil
+	return results, nil
 }
 
-func (n *RequestNode) extractOutputs(respCtx extractors.ResponseContext) (map[string]interface{}, error) {
+func (n *RequestNode) extractOutputs(
+	ctx ExecutionContext, respCtx extractors.ResponseContext,
+) (map[string]interface{}, error) {
 	output := make(map[string]interface{})
 
-	log.Debug().
+	loggerOf(ctx).Debug().
 		Str("nodeID", n.GetID()).
 		Int("extractorCount", len(n.GetOutputs())).
 		Msg("Extracting outputs")
 
 	for _, outputItem := range n.GetOutputs() {
-		log.Debug().
+		loggerOf(ctx).Debug().
 			Str("nodeID", n.GetID()).
 			Str("extractorType", string(outputItem.Extractor.GetType())).
 			Str("outputName", outputItem.Name).
@@ -146,7 +208,7 @@ func (n *RequestNode) extractOutputs(respCtx extractors.ResponseContext) (map[st
 
 		value, extractErr := outputItem.Extractor.Extract(respCtx)
 		if extractErr != nil {
-			log.Error().
+			loggerOf(ctx).Error().
 				Str("nodeID", n.GetID()).
 				Str("outputName", outputItem.Name).
 				Str("extractorType", string(outputItem.Extractor.GetType())).
@@ -155,7 +217,10 @@ func (n *RequestNode) extractOutputs(respCtx extractors.ResponseContext) (map[st
 			return nil, extractErr
 		}
 		output[outputItem.Name] = value
-		log.Debug().
+		if outputItem.Secret {
+			ctx.Redactor.Add(value)
+		}
+		loggerOf(ctx).Debug().
 			Str("nodeID", n.GetID()).
 			Str("outputName", outputItem.Name).
 			Any("value", value).
@@ -165,11 +230,11 @@ func (n *RequestNode) extractOutputs(respCtx extractors.ResponseContext) (map[st
 	return output, nil
 }
 
-func (n *RequestNode) validateOutput(output map[string]interface{}) error {
+func (n *RequestNode) validateOutput(ctx ExecutionContext, output map[string]interface{}) error {
 	for _, expectedKey := range n.OutputSchema() {
 		if _, exists := output[expectedKey]; !exists {
 			errOutput := fmt.Errorf("failed to extract expected output: %s", expectedKey)
-			log.Error().
+			loggerOf(ctx).Error().
 				Str("nodeID", n.GetID()).
 				Str("expectedOutput", expectedKey).
 				Err(errOutput).
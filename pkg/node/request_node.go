@@ -1,26 +1,26 @@
 package node
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
-	"fmt"
+	"errors"
 	"io"
+	"maps"
 	"net/http"
-	"strings"
 	"time"
-
-	"github.com/rs/zerolog/log"
-
-	"github.com/nanostack-dev/echopoint-flow-engine/pkg/extractors"
 )
 
 type RequestData struct {
 	Method      string                 `json:"method"`
 	URL         string                 `json:"url"`
 	Headers     map[string]string      `json:"headers"`
+	PathParams  map[string]interface{} `json:"pathParams,omitempty"`
 	QueryParams map[string]interface{} `json:"queryParams"`
 	Body        interface{}            `json:"body"`
+	BodyType    BodyType               `json:"bodyType,omitempty"`
 	Timeout     int                    `json:"timeout"`
+	Retry       *RetryPolicy           `json:"retry,omitempty"`
+	Auth        *Auth                  `json:"auth,omitempty"`
 }
 
 // RequestNode is a typed node for HTTP requests.
@@ -59,7 +59,7 @@ func (n *RequestNode) GetAssertions() []CompositeAssertion {
 	return n.Assertions
 }
 
-// InputSchema infers inputs from template variables in URL, Headers, QueryParams, and Body.
+// InputSchema infers inputs from template variables in URL, Headers, PathParams, QueryParams, Body, and Auth.
 func (n *RequestNode) InputSchema() []string {
 	si := &SchemaInference{}
 	return si.InferRequestNodeInputSchema(n.Data)
@@ -74,56 +74,32 @@ func (n *RequestNode) OutputSchema() []string {
 func (n *RequestNode) Execute(ctx ExecutionContext) (AnyExecutionResult, error) {
 	startTime := time.Now()
 
-	log.Debug().
+	loggerOf(ctx).Debug().
 		Str("nodeID", n.GetID()).
 		Any("inputs", ctx.Inputs).
 		Msg("Starting request node execution")
 
-	if err := n.validateInputsPresent(ctx.Inputs); err != nil {
+	if err := n.validateInputsPresent(ctx); err != nil {
 		return n.createErrorResult(ctx.Inputs, err, time.Since(startTime)), err
 	}
 
-	url, headers, body, err := n.prepareRequest(ctx.Inputs)
+	prepared, encodedBody, err := n.buildRequest(ctx)
 	if err != nil {
 		return n.createErrorResult(ctx.Inputs, err, time.Since(startTime)), err
 	}
 
-	resp, respBody, err := n.makeRequestAndReadBody(url, n.Data.Method, headers, body, n.Data.Timeout)
+	outcome, attempts, err := n.performAttempts(ctx, prepared, encodedBody)
 	if err != nil {
-		log.Error().
+		loggerOf(ctx).Error().
 			Str("nodeID", n.GetID()).
 			Str("method", n.Data.Method).
-			Str("url", url).
+			Str("url", prepared.url).
+			Int("attempts", len(attempts)).
 			Err(err).
 			Msg("HTTP request failed")
-		return n.createErrorResult(ctx.Inputs, err, time.Since(startTime)), err
-	}
-	defer resp.Body.Close()
-
-	log.Debug().
-		Str("nodeID", n.GetID()).
-		Int("statusCode", resp.StatusCode).
-		Msg("HTTP response received")
-
-	log.Debug().
-		Str("nodeID", n.GetID()).
-		Int("bodySize", len(respBody)).
-		Msg("Response body read")
-
-	parsedBody := n.parseResponseBody(resp.Header.Get("Content-Type"), respBody)
-	respCtx := extractors.NewResponseContext(resp, respBody, parsedBody)
-
-	if assertErr := n.runAssertions(respCtx); assertErr != nil {
-		return n.createErrorResult(ctx.Inputs, assertErr, time.Since(startTime)), assertErr
-	}
-
-	outputs, err := n.extractOutputs(respCtx)
-	if err != nil {
-		return n.createErrorResult(ctx.Inputs, err, time.Since(startTime)), err
-	}
-
-	if validateErr := n.validateOutput(outputs); validateErr != nil {
-		return n.createErrorResult(ctx.Inputs, validateErr, time.Since(startTime)), validateErr
+		errResult := n.createErrorResult(ctx.Inputs, err, time.Since(startTime))
+		errResult.Attempts = attempts
+		return errResult, err
 	}
 
 	// Create typed RequestExecutionResult with all HTTP data
@@ -133,42 +109,126 @@ func (n *RequestNode) Execute(ctx ExecutionContext) (AnyExecutionResult, error)
 			DisplayName: n.GetDisplayName(),
 			NodeType:    TypeRequest,
 			Inputs:      ctx.Inputs,
-			Outputs:     outputs,
-			ExecutedAt:  time.Now(),
 		},
 		// HTTP Request
 		RequestMethod:  n.Data.Method,
-		RequestURL:     url,
-		RequestHeaders: headers,
-		RequestBody:    body,
+		RequestURL:     prepared.url,
+		RequestHeaders: recordedHeaders(outcome.headers, prepared.auth, ctx.Redactor),
+		RequestBody:    prepared.body,
 
 		// HTTP Response
-		ResponseStatusCode: resp.StatusCode,
-		ResponseHeaders:    resp.Header,
-		ResponseBody:       respBody,
-		ResponseBodyParsed: parsedBody,
+		ResponseStatusCode: outcome.resp.StatusCode,
+		ResponseHeaders:    outcome.resp.Header,
+		ResponseBody:       outcome.respBody,
+		ResponseBodyParsed: outcome.parsedBody,
 
-		DurationMs: time.Since(startTime).Milliseconds(),
+		AssertionResults: outcome.assertionResults,
+		Attempts:         attempts,
 	}
 
-	log.Info().
+	if outcome.assertErr != nil {
+		return n.failResult(result, outcome.assertErr, ErrorCodeAssertionFailed, startTime), outcome.assertErr
+	}
+
+	outputs, err := n.extractOutputs(ctx, outcome.respCtx)
+	if err != nil {
+		return n.failResult(result, err, ErrorCodeExtractionFailed, startTime), err
+	}
+
+	if validateErr := n.validateOutput(ctx, outputs); validateErr != nil {
+		return n.failResult(result, validateErr, ErrorCodeExtractionFailed, startTime), validateErr
+	}
+
+	result.Outputs = outputs
+	result.ExecutedAt = time.Now()
+	result.DurationMs = time.Since(startTime).Milliseconds()
+
+	loggerOf(ctx).Info().
 		Str("nodeID", n.GetID()).
 		Int("outputCount", len(outputs)).
-		Int("statusCode", resp.StatusCode).
+		Int("statusCode", outcome.resp.StatusCode).
 		Int64("durationMs", result.DurationMs).
 		Msg("Request node executed successfully")
 
 	return result, nil
 }
 
+// buildRequest resolves the templates of the request and encodes its body. Credentials that depend
+// on the time of sending are added to every attempt, see authenticate.
+func (n *RequestNode) buildRequest(ctx ExecutionContext) (*preparedRequest, *requestBody, error) {
+	prepared, err := n.prepareRequest(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encodedBody, err := encodeRequestBody(n.Data.BodyType, prepared.body, prepared.headers)
+	if err != nil {
+		loggerOf(ctx).Error().
+			Str("nodeID", n.GetID()).
+			Str("bodyType", string(n.Data.BodyType)).
+			Err(err).
+			Msg("Request body encoding failed")
+		return nil, nil, err
+	}
+	return prepared, encodedBody, nil
+}
+
+// authenticate returns the headers of one attempt: a copy of the prepared headers with a fresh
+// OAuth2 token or HMAC signature, so that retries and polls never send expired credentials.
+func (n *RequestNode) authenticate(
+	ctx ExecutionContext, prepared *preparedRequest, body *requestBody,
+) (map[string]string, error) {
+	if prepared.auth == nil {
+		return prepared.headers, nil
+	}
+	headers := maps.Clone(prepared.headers)
+	if err := prepared.auth.applyDynamic(ctx, n.Data.Method, prepared.url, headers, body); err != nil {
+		loggerOf(ctx).Error().
+			Str("nodeID", n.GetID()).
+			Str("authType", string(prepared.auth.Type)).
+			Err(err).
+			Msg("Request authentication failed")
+		return nil, err
+	}
+	return headers, nil
+}
+
+// failResult marks a RequestExecutionResult that already holds response data as failed.
+func (n *RequestNode) failResult(
+	result *RequestExecutionResult,
+	err error,
+	errCode ErrorCode,
+	startTime time.Time,
+) AnyExecutionResult {
+	errMsg := err.Error()
+	result.Error = err
+	result.ErrorMsg = &errMsg
+	result.ErrorCode = &errCode
+	result.ExecutedAt = time.Now()
+	result.DurationMs = time.Since(startTime).Milliseconds()
+	return result
+}
+
 // createErrorResult creates a RequestExecutionResult for error cases.
 func (n *RequestNode) createErrorResult(
 	inputs map[string]interface{},
 	err error,
 	duration time.Duration,
-) AnyExecutionResult {
+) *RequestExecutionResult {
 	errMsg := err.Error()
-	errCode := "REQUEST_FAILED"
+	errCode := ErrorCodeRequestFailed
+	var unresolvedErr *UnresolvedTemplateError
+	var authErr *AuthError
+	switch {
+	case errors.As(err, &unresolvedErr):
+		errCode = ErrorCodeUnresolvedTemplate
+	case errors.As(err, &authErr):
+		errCode = ErrorCodeAuthFailed
+	case errors.Is(err, context.Canceled):
+		errCode = ErrorCodeCancelled
+	case errors.Is(err, context.DeadlineExceeded):
+		errCode = ErrorCodeTimeout
+	}
 
 	return &RequestExecutionResult{
 		BaseExecutionResult: BaseExecutionResult{
@@ -186,52 +246,29 @@ func (n *RequestNode) createErrorResult(
 	}
 }
 
-func (n *RequestNode) resolveTemplates(
-	value interface{}, inputs map[string]interface{},
-) interface{} {
-	resolver := NewTemplateResolver(inputs)
-	resolved, err := resolver.Resolve(value)
-	if err != nil {
-		return value
-	}
-	return resolved
-}
-
-// resolveTemplatesWithError is like resolveTemplates but returns errors.
-func (n *RequestNode) resolveTemplatesWithError(
-	value interface{}, inputs map[string]interface{},
-) (string, error) {
-	resolver := NewTemplateResolver(inputs)
-	resolved, err := resolver.Resolve(value)
-	if err != nil {
-		return "", err
-	}
-	result, ok := resolved.(string)
-	if !ok {
-		return "", fmt.Errorf("expected string, got %T", resolved)
-	}
-	return result, nil
-}
-
-func (n *RequestNode) validate(
-	_ CompositeAssertion, _ extractors.ResponseContext,
-) bool {
-	// TODO: Implement validation using extractor and operator factories
-	// This requires creating factory functions for extractors and operators
-	// For now, return true to allow basic flow execution
-	// The context now provides access to status, headers, body, parsed body via interfaces
-	return true
-}
-
 // makeRequestAndReadBody makes an HTTP request and reads the entire response body
 // within the timeout period. The timeout applies to the entire operation (request + body read).
+// Cancelling parent interrupts the request; a timeout of zero means no per-request timeout.
 func (n *RequestNode) makeRequestAndReadBody(
-	url, method string, headers map[string]string, body interface{}, timeout int,
+	parent context.Context,
+	client *http.Client,
+	url, method string,
+	headers map[string]string,
+	body *requestBody,
+	timeout int,
 ) (*http.Response, []byte, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Millisecond)
-	defer cancel()
+	ctx := parent
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(parent, time.Duration(timeout)*time.Millisecond)
+		defer cancel()
+	}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	var payload io.Reader
+	if body != nil {
+		payload = bytes.NewReader(body.payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, payload)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -239,16 +276,9 @@ func (n *RequestNode) makeRequestAndReadBody(
 		req.Header.Set(key, value)
 	}
 	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
-		jsonBody, marshalErr := json.Marshal(body)
-		if marshalErr != nil {
-			return nil, nil, marshalErr
-		}
-		req.Body = io.NopCloser(strings.NewReader(string(jsonBody)))
-		req.ContentLength = int64(len(jsonBody))
+		req.Header.Set(contentTypeHeader, body.contentType)
 	}
 
-	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, nil, err
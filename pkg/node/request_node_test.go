@@ -0,0 +1,369 @@
+package node_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/internal/logger"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/node"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/operators"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	// Enable debug logging with human-readable format for tests
+	logger.SetDebugLogging()
+}
+
+// newUserServer returns a test server that answers every request with a created user.
+func newUserServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(`{"user": {"id": "123", "name": "Alice"}}`))
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func unmarshalRequestNode(t *testing.T, data string) *node.RequestNode {
+	t.Helper()
+	anyNode, err := node.UnmarshalNode([]byte(data))
+	require.NoError(t, err)
+	return node.MustAsRequestNode(anyNode)
+}
+
+func TestCompositeAssertion_UnmarshalOperator(t *testing.T) {
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "req",
+		"type": "request",
+		"assertions": [
+			{"extractor": {"type": "statusCode"}, "operator": {"type": "between", "min": 200, "max": 299}}
+		],
+		"data": {"method": "GET", "url": "http://localhost"}
+	}`,
+	)
+
+	require.Len(t, reqNode.GetAssertions(), 1)
+	assert.Equal(
+		t, operators.BetweenOperator{Min: 200, Max: 299}, reqNode.GetAssertions()[0].Operator,
+	)
+}
+
+func TestRequestNode_Execute_AssertionsPass(t *testing.T) {
+	server := newUserServer(t)
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "create-user",
+		"type": "request",
+		"assertions": [
+			{"extractor": {"type": "statusCode"}, "operator": {"type": "equals", "expected": 201}},
+			{"extractor": {"type": "jsonPath", "path": "$.user.name"}, "operator": {"type": "startsWith", "prefix": "Al"}}
+		],
+		"data": {"method": "POST", "url": "`+server.URL+`", "timeout": 5000}
+	}`,
+	)
+
+	result, err := reqNode.Execute(node.ExecutionContext{Inputs: map[string]interface{}{}})
+
+	require.NoError(t, err)
+	reqResult := node.MustAsRequestExecutionResult(result)
+	require.Len(t, reqResult.AssertionResults, 2)
+	for _, assertionResult := range reqResult.AssertionResults {
+		assert.True(t, assertionResult.Passed, assertionResult.Message)
+	}
+	assert.Equal(t, 201, reqResult.AssertionResults[0].Actual)
+	assert.Equal(t, "Alice", reqResult.AssertionResults[1].Actual)
+}
+
+func TestRequestNode_Execute_AssertionsFail(t *testing.T) {
+	server := newUserServer(t)
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "create-user",
+		"type": "request",
+		"assertions": [
+			{"extractor": {"type": "statusCode"}, "operator": {"type": "equals", "expected": 200}},
+			{"extractor": {"type": "jsonPath", "path": "$.user.id"}, "operator": {"type": "notEmpty"}},
+			{"extractor": {"type": "jsonPath", "path": "$.user.missing"}, "operator": {"type": "notEmpty"}}
+		],
+		"data": {"method": "POST", "url": "`+server.URL+`", "timeout": 5000}
+	}`,
+	)
+
+	result, err := reqNode.Execute(node.ExecutionContext{Inputs: map[string]interface{}{}})
+
+	require.Error(t, err)
+	var assertionErr *node.AssertionError
+	require.ErrorAs(t, err, &assertionErr)
+	require.Len(t, assertionErr.Failures, 2)
+	assert.Equal(t, 0, assertionErr.Failures[0].Index)
+	assert.Equal(t, 2, assertionErr.Failures[1].Index)
+
+	reqResult := node.MustAsRequestExecutionResult(result)
+	assert.Equal(t, http.StatusCreated, reqResult.ResponseStatusCode, "response should be kept on failure")
+	require.Len(t, reqResult.AssertionResults, 3)
+
+	statusResult := reqResult.AssertionResults[0]
+	assert.False(t, statusResult.Passed)
+	assert.Equal(t, operators.OperatorTypeEquals, statusResult.OperatorType)
+	assert.Equal(t, operators.EqualsOperator{Expected: float64(200)}, statusResult.Expected)
+	assert.Equal(t, 201, statusResult.Actual)
+	assert.Contains(t, statusResult.Message, "statusCode equals")
+
+	assert.True(t, reqResult.AssertionResults[1].Passed)
+	assert.Contains(t, reqResult.AssertionResults[2].Message, "extraction failed")
+
+	require.NotNil(t, reqResult.ErrorCode)
+	assert.Equal(t, node.ErrorCodeAssertionFailed, *reqResult.ErrorCode)
+	assert.ErrorIs(t, result.GetError(), err)
+}
+
+func TestRequestNode_Execute_ContextCancellation(t *testing.T) {
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(_ http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "slow",
+		"type": "request",
+		"data": {"method": "GET", "url": "`+server.URL+`"}
+	}`,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	result, err := reqNode.Execute(node.ExecutionContext{Context: ctx, Inputs: map[string]interface{}{}})
+
+	require.ErrorIs(t, err, context.Canceled)
+	reqResult := node.MustAsRequestExecutionResult(result)
+	require.NotNil(t, reqResult.ErrorCode)
+	assert.Equal(t, node.ErrorCodeCancelled, *reqResult.ErrorCode)
+}
+
+func TestRequestNode_Execute_XMLAssertions(t *testing.T) {
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+				_, _ = w.Write([]byte(`<user id="123"><name>Alice</name></user>`))
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "get-user",
+		"type": "request",
+		"assertions": [
+			{"extractor": {"type": "xmlPath", "path": "/user/@id"}, "operator": {"type": "equals", "expected": "123"}},
+			{"extractor": {"type": "xmlPath", "path": "/user/name"}, "operator": {"type": "equals", "expected": "Alice"}}
+		],
+		"data": {"method": "GET", "url": "`+server.URL+`"}
+	}`,
+	)
+
+	result, err := reqNode.Execute(node.ExecutionContext{Inputs: map[string]interface{}{}})
+
+	require.NoError(t, err)
+	reqResult := node.MustAsRequestExecutionResult(result)
+	assert.Equal(t, `<user id="123"><name>Alice</name></user>`, reqResult.ResponseBodyParsed)
+	require.Len(t, reqResult.AssertionResults, 2)
+	assert.True(t, reqResult.AssertionResults[1].Passed, reqResult.AssertionResults[1].Message)
+}
+
+func TestRequestNode_Execute_TypedBodyTemplates(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewDecoder(r.Body).Decode(&received)
+				w.WriteHeader(http.StatusNoContent)
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "create-order",
+		"type": "request",
+		"data": {
+			"method": "POST",
+			"url": "`+server.URL+`/users/{{create-user.body.user.id}}/orders",
+			"body": {
+				"quantity": "{{quantity}}",
+				"user": "{{create-user.body.user}}",
+				"label": "{{ create-user.body.user.name | upper }}-{{quantity}}"
+			}
+		}
+	}`,
+	)
+	assert.ElementsMatch(t, []string{"create-user.body", "quantity"}, reqNode.InputSchema())
+
+	result, err := reqNode.Execute(
+		node.ExecutionContext{
+			Inputs: map[string]interface{}{
+				"quantity":         float64(2),
+				"create-user.body": map[string]interface{}{"user": map[string]interface{}{"id": "123", "name": "Alice"}},
+			},
+		},
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/users/123/orders", node.MustAsRequestExecutionResult(result).RequestURL)
+	assert.Equal(
+		t, map[string]interface{}{
+			"quantity": float64(2),
+			"user":     map[string]interface{}{"id": "123", "name": "Alice"},
+			"label":    "ALICE-2",
+		}, received,
+	)
+}
+
+func TestRequestNode_Execute_UnresolvedTemplatesFail(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, _ *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(http.StatusNoContent)
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "update-user",
+		"type": "request",
+		"data": {
+			"method": "PUT",
+			"url": "`+server.URL+`/users/{{user.body.id}}",
+			"headers": {"Authorization": "Bearer {{user.body.token}}"},
+			"queryParams": {"tenant": "{{user.body.tenant}}"},
+			"body": {"profile": {"tags": ["{{user.body.tags[3]}}"]}}
+		}
+	}`,
+	)
+
+	result, err := reqNode.Execute(
+		node.ExecutionContext{Inputs: map[string]interface{}{"user.body": map[string]interface{}{}}},
+	)
+
+	var unresolvedErr *node.UnresolvedTemplateError
+	require.ErrorAs(t, err, &unresolvedErr)
+	assert.Equal(t, "update-user", unresolvedErr.NodeID)
+	assert.Equal(
+		t, []node.UnresolvedReference{
+			{Variable: "user.body.tags[3]", Location: "body.profile.tags[0]"},
+			{Variable: "user.body.token", Location: "header:Authorization"},
+			{Variable: "user.body.tenant", Location: "query:tenant"},
+			{Variable: "user.body.id", Location: "url"},
+		}, unresolvedErr.References,
+	)
+	assert.Contains(t, err.Error(), "user.body.id (url)")
+	assert.Equal(t, int32(0), calls.Load(), "request must not be sent")
+
+	reqResult := node.MustAsRequestExecutionResult(result)
+	require.NotNil(t, reqResult.ErrorCode)
+	assert.Equal(t, node.ErrorCodeUnresolvedTemplate, *reqResult.ErrorCode)
+}
+
+func TestRequestNode_Execute_LenientTemplates(t *testing.T) {
+	server := newUserServer(t)
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "lenient",
+		"type": "request",
+		"data": {"method": "GET", "url": "`+server.URL+`/users/{{user.body.id}}"}
+	}`,
+	)
+
+	result, err := reqNode.Execute(
+		node.ExecutionContext{
+			Inputs:           map[string]interface{}{"user.body": map[string]interface{}{}},
+			LenientTemplates: true,
+		},
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/users/{{user.body.id}}", node.MustAsRequestExecutionResult(result).RequestURL)
+}
+
+func TestRequestNode_Execute_BuildsURL(t *testing.T) {
+	var requestURI string
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				requestURI = r.RequestURI
+				w.WriteHeader(http.StatusNoContent)
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "search",
+		"type": "request",
+		"data": {
+			"method": "GET",
+			"url": "`+server.URL+`/orgs/{org}/users/{id}?signature=a%2Fb",
+			"pathParams": {"org": "acme corp", "id": "{{userId}}"},
+			"queryParams": {
+				"q": "name:{{name}} & more",
+				"tag": ["a", "b c"],
+				"limit": 10
+			}
+		}
+	}`,
+	)
+	assert.ElementsMatch(t, []string{"name", "userId"}, reqNode.InputSchema())
+
+	for range 3 {
+		result, err := reqNode.Execute(
+			node.ExecutionContext{Inputs: map[string]interface{}{"userId": "a/1", "name": "Zoë"}},
+		)
+
+		require.NoError(t, err)
+		expected := "/orgs/acme%20corp/users/a%2F1?signature=a%2Fb&limit=10&q=name%3AZo%C3%AB+%26+more&tag=a&tag=b+c"
+		assert.Equal(t, expected, requestURI)
+		assert.Equal(t, server.URL+expected, node.MustAsRequestExecutionResult(result).RequestURL)
+	}
+}
+
+func TestRequestNode_Execute_UnknownPathParameter(t *testing.T) {
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "get-user",
+		"type": "request",
+		"data": {"method": "GET", "url": "http://localhost/users/{id}", "pathParams": {"userId": "1"}}
+	}`,
+	)
+
+	_, err := reqNode.Execute(node.ExecutionContext{Inputs: map[string]interface{}{}})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "path parameter 'userId' has no {userId} placeholder in URL")
+}
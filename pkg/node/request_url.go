@@ -0,0 +1,57 @@
+package node
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// buildRequestURL substitutes path parameters and appends query parameters to a resolved URL.
+// Path parameters replace "{name}" placeholders with the escaped value. Query parameters are
+// encoded in key order after any query already present in the URL, which is kept untouched;
+// array values become repeated keys.
+func buildRequestURL(rawURL string, pathParams, queryParams map[string]interface{}) (string, error) {
+	names := make([]string, 0, len(pathParams))
+	for name := range pathParams {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		placeholder := "{" + name + "}"
+		if !strings.Contains(rawURL, placeholder) {
+			return "", fmt.Errorf("path parameter '%s' has no %s placeholder in URL", name, placeholder)
+		}
+		rawURL = strings.ReplaceAll(rawURL, placeholder, url.PathEscape(formatTemplateValue(pathParams[name])))
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL '%s': %w", rawURL, err)
+	}
+	if len(queryParams) == 0 {
+		return rawURL, nil
+	}
+
+	query := url.Values{}
+	for key, value := range queryParams {
+		addValues(query, key, value)
+	}
+	if parsed.RawQuery == "" {
+		parsed.RawQuery = query.Encode()
+	} else {
+		parsed.RawQuery += "&" + query.Encode()
+	}
+	return parsed.String(), nil
+}
+
+// addValues adds a parameter to values, repeating the key for every element of an array.
+func addValues(values url.Values, key string, value interface{}) {
+	if items, isList := value.([]interface{}); isList {
+		for _, item := range items {
+			values.Add(key, formatTemplateValue(item))
+		}
+		return
+	}
+	values.Add(key, formatTemplateValue(value))
+}
@@ -0,0 +1,84 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// UnmarshalJSON decodes a result written by json.Marshal, restoring the type of every execution
+// result from its node type. Results without schema version predate versioning and are accepted.
+func (r *FlowExecutionResult) UnmarshalJSON(data []byte) error {
+	type plainResult FlowExecutionResult
+	raw := struct {
+		*plainResult
+
+		ExecutionResults map[string]json.RawMessage `json:"execution_results"`
+	}{plainResult: (*plainResult)(r)}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if r.SchemaVersion != "" && r.SchemaVersion != ResultSchemaVersion {
+		return fmt.Errorf("unsupported result schema version: %s", r.SchemaVersion)
+	}
+
+	r.ExecutionResults = make(map[string]AnyExecutionResult, len(raw.ExecutionResults))
+	for nodeID, rawResult := range raw.ExecutionResults {
+		result, err := UnmarshalExecutionResult(rawResult)
+		if err != nil {
+			return fmt.Errorf("execution result of node '%s': %w", nodeID, err)
+		}
+		r.ExecutionResults[nodeID] = result
+	}
+	r.Error = restoreError(r.ErrorMsg)
+	return nil
+}
+
+// UnmarshalExecutionResult unmarshals JSON into the execution result type matching its node_type field.
+// Results of node types defined outside this package are unmarshaled into a BaseExecutionResult.
+// Since errors are recorded by their message, GetError returns an error carrying that message.
+func UnmarshalExecutionResult(data []byte) (AnyExecutionResult, error) {
+	var peek struct {
+		NodeType Type `json:"node_type"`
+	}
+	if err := json.Unmarshal(data, &peek); err != nil {
+		return nil, fmt.Errorf("failed to peek node type: %w", err)
+	}
+
+	var result AnyExecutionResult
+	switch peek.NodeType {
+	case TypeRequest:
+		result = &RequestExecutionResult{}
+	case TypeDelay:
+		result = &DelayExecutionResult{}
+	case TypeForEach:
+		result = &ForEachExecutionResult{}
+	case TypeCondition:
+		result = &ConditionExecutionResult{}
+	case TypeTransform:
+		result = &TransformExecutionResult{}
+	case TypeSubflow:
+		result = &SubflowExecutionResult{}
+	case TypePoll:
+		result = &PollExecutionResult{}
+	default:
+		result = &BaseExecutionResult{}
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s execution result: %w", peek.NodeType, err)
+	}
+	result.restoreError()
+	return result, nil
+}
+
+func (b *BaseExecutionResult) restoreError() {
+	b.Error = restoreError(b.ErrorMsg)
+}
+
+// restoreError returns an error carrying a recorded error message, nil when there is none.
+func restoreError(errMsg *string) error {
+	if errMsg == nil {
+		return nil
+	}
+	return errors.New(*errMsg)
+}
@@ -0,0 +1,101 @@
+package node_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/node"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlowExecutionResult_JSONRoundTrip(t *testing.T) {
+	executedAt := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	loginErr := "assertion failed"
+	assertionCode := node.ErrorCodeAssertionFailed
+	subflowErr := "subflow failed: assertion failed"
+	subflowCode := node.ErrorCodeSubflowFailed
+	flowErr := subflowErr
+	flowCode := node.ErrorCodeNodeFailed
+
+	child := &node.FlowExecutionResult{
+		SchemaVersion: node.ResultSchemaVersion,
+		ExecutionResults: map[string]node.AnyExecutionResult{
+			"login": &node.RequestExecutionResult{
+				BaseExecutionResult: node.BaseExecutionResult{
+					NodeID:     "login",
+					NodeType:   node.TypeRequest,
+					Error:      errors.New(loginErr),
+					ErrorMsg:   &loginErr,
+					ErrorCode:  &assertionCode,
+					ExecutedAt: executedAt,
+				},
+				RequestMethod:      "POST",
+				ResponseStatusCode: 401,
+			},
+		},
+		FinalOutputs: map[string]interface{}{},
+		NodeStatuses: map[string]node.NodeStatus{"login": node.NodeStatusFailed},
+	}
+	original := &node.FlowExecutionResult{
+		SchemaVersion: node.ResultSchemaVersion,
+		ExecutionResults: map[string]node.AnyExecutionResult{
+			"route": &node.ConditionExecutionResult{
+				BaseExecutionResult: node.BaseExecutionResult{
+					NodeID: "route", NodeType: node.TypeCondition, ExecutedAt: executedAt,
+				},
+				SelectedBranches: []string{"admin"},
+			},
+			"authenticate": &node.SubflowExecutionResult{
+				BaseExecutionResult: node.BaseExecutionResult{
+					NodeID:     "authenticate",
+					NodeType:   node.TypeSubflow,
+					ErrorMsg:   &subflowErr,
+					ErrorCode:  &subflowCode,
+					ExecutedAt: executedAt,
+				},
+				Flow:   &node.FlowReference{Name: "Login", Version: "2"},
+				Result: child,
+			},
+			"audit": &node.BaseExecutionResult{NodeID: "audit", NodeType: "custom", ExecutedAt: executedAt},
+		},
+		FinalOutputs: map[string]interface{}{"route.branch": "admin"},
+		NodeStatuses: map[string]node.NodeStatus{
+			"route": node.NodeStatusSucceeded, "authenticate": node.NodeStatusFailed, "audit": node.NodeStatusSkipped,
+		},
+		ErrorMsg:  &flowErr,
+		ErrorCode: &flowCode,
+	}
+	data, err := json.Marshal(original)
+	require.NoError(t, err)
+
+	var decoded node.FlowExecutionResult
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	redecoded, err := json.Marshal(&decoded)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(data), string(redecoded))
+	assert.Equal(t, node.ResultSchemaVersion, decoded.SchemaVersion)
+	assert.EqualError(t, decoded.Error, flowErr)
+	route := node.MustAsConditionExecutionResult(decoded.ExecutionResults["route"])
+	assert.Equal(t, []string{"admin"}, route.SelectedBranches)
+	assert.Equal(t, node.Type("custom"), decoded.ExecutionResults["audit"].GetNodeType())
+
+	subflowResult := node.MustAsSubflowExecutionResult(decoded.ExecutionResults["authenticate"])
+	assert.EqualError(t, subflowResult.GetError(), subflowErr)
+	login := node.MustAsRequestExecutionResult(subflowResult.Result.ExecutionResults["login"])
+	assert.Equal(t, 401, login.ResponseStatusCode)
+	assert.Equal(t, node.ErrorCodeAssertionFailed, *login.ErrorCode)
+	assert.EqualError(t, login.GetError(), loginErr)
+}
+
+func TestFlowExecutionResult_UnmarshalJSON_UnsupportedVersion(t *testing.T) {
+	var result node.FlowExecutionResult
+
+	err := json.Unmarshal([]byte(`{"schema_version": "2", "success": true}`), &result)
+
+	require.Error(t, err)
+	assert.Equal(t, "unsupported result schema version: 2", err.Error())
+}
@@ -0,0 +1,312 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/extractors"
+)
+
+// BackoffType selects how the delay between retry attempts grows.
+type BackoffType string
+
+const (
+	BackoffFixed       BackoffType = "fixed"
+	BackoffExponential BackoffType = "exponential"
+)
+
+// RetryErrorClass groups transport errors that can be retried.
+type RetryErrorClass string
+
+const (
+	RetryOnTimeout    RetryErrorClass = "timeout"    // The per-request timeout elapsed
+	RetryOnConnection RetryErrorClass = "connection" // Connection refused, reset or closed early
+	RetryOnDNS        RetryErrorClass = "dns"        // Host name could not be resolved
+)
+
+const (
+	defaultBackoffMultiplier = 2.0
+	// defaultMaxBackoffDelayMs bounds exponential backoff when the policy sets no maximum.
+	defaultMaxBackoffDelayMs = 30000
+)
+
+// defaultRetryStatusCodes are retried when a policy lists neither status codes nor error classes.
+//
+//nolint:gochecknoglobals // read-only defaults
+var defaultRetryStatusCodes = []int{429, 502, 503, 504}
+
+// RetryPolicy configures how a request node retries failed attempts.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one
+	MaxAttempts int `json:"maxAttempts"`
+	// Backoff controls the delay between attempts
+	Backoff BackoffPolicy `json:"backoff"`
+	// RetryOnStatusCodes lists response status codes that trigger a retry
+	RetryOnStatusCodes []int `json:"retryOnStatusCodes,omitempty"`
+	// RetryOnErrors lists transport error classes that trigger a retry
+	RetryOnErrors []RetryErrorClass `json:"retryOnErrors,omitempty"`
+	// UntilAssertionsPass retries while any assertion fails, for polling eventually-consistent endpoints
+	UntilAssertionsPass bool `json:"untilAssertionsPass,omitempty"`
+	// RetryUnsafeMethods retries timeouts and connection errors of non-idempotent requests such as POST
+	// and PATCH. Such requests may already have reached the server, so by default they are not re-sent.
+	RetryUnsafeMethods bool `json:"retryUnsafeMethods,omitempty"`
+}
+
+// BackoffPolicy describes the delay between two attempts.
+type BackoffPolicy struct {
+	Type           BackoffType `json:"type"`                 // fixed (default) or exponential
+	InitialDelayMs int         `json:"initialDelayMs"`       // Delay before the second attempt
+	MaxDelayMs     int         `json:"maxDelayMs,omitempty"` // Upper bound for the delay, 30s by default for exponential
+	Multiplier     float64     `json:"multiplier,omitempty"` // Growth factor for exponential backoff, defaults to 2
+	Jitter         float64     `json:"jitter,omitempty"`     // Random spread as a fraction of the delay (0..1)
+}
+
+// RequestAttempt records a single HTTP attempt made by a request node.
+type RequestAttempt struct {
+	Attempt     int       `json:"attempt"`
+	StatusCode  int       `json:"status_code,omitempty"`
+	Error       *string   `json:"error,omitempty"`
+	RetryReason string    `json:"retry_reason,omitempty"` // Why another attempt followed, empty for the last one
+	DelayMs     int64     `json:"delay_ms,omitempty"`     // Wait before the next attempt
+	StartedAt   time.Time `json:"started_at"`
+	DurationMs  int64     `json:"duration_ms"`
+}
+
+// attemptOutcome holds the response of a completed attempt and its assertion results.
+type attemptOutcome struct {
+	headers          map[string]string // Headers sent, credentials included
+	resp             *http.Response
+	respBody         []byte
+	parsedBody       interface{}
+	respCtx          extractors.ResponseContext
+	assertionResults []AssertionResult
+	assertErr        error
+}
+
+// performAttempts sends the request until it succeeds or the retry policy gives up.
+// It returns the outcome of the last attempt together with the record of every attempt.
+// Authentication failures are not retried.
+func (n *RequestNode) performAttempts(
+	ctx ExecutionContext, prepared *preparedRequest, body *requestBody,
+) (*attemptOutcome, []RequestAttempt, error) {
+	policy := n.Data.Retry
+	maxAttempts := policy.attempts()
+	attempts := make([]RequestAttempt, 0, maxAttempts)
+
+	for attemptNum := 1; ; attemptNum++ {
+		headers, authErr := n.authenticate(ctx, prepared, body)
+		if authErr != nil {
+			return nil, attempts, authErr
+		}
+		attemptStart := time.Now()
+		outcome, err := n.attempt(ctx, prepared.url, headers, body)
+		record := RequestAttempt{
+			Attempt:    attemptNum,
+			StartedAt:  attemptStart,
+			DurationMs: time.Since(attemptStart).Milliseconds(),
+		}
+
+		var statusCode int
+		var assertErr error
+		if err != nil {
+			errMsg := err.Error()
+			record.Error = &errMsg
+		} else {
+			statusCode = outcome.resp.StatusCode
+			assertErr = outcome.assertErr
+			record.StatusCode = statusCode
+		}
+
+		var reason string
+		if attemptNum < maxAttempts {
+			reason = policy.retryReason(contextOf(ctx), n.Data.Method, statusCode, err, assertErr)
+		}
+		if reason == "" {
+			attempts = append(attempts, record)
+			return outcome, attempts, err
+		}
+
+		delay := policy.Backoff.delay(attemptNum)
+		record.RetryReason = reason
+		record.DelayMs = delay.Milliseconds()
+		attempts = append(attempts, record)
+
+		loggerOf(ctx).Warn().
+			Str("nodeID", n.GetID()).
+			Int("attempt", attemptNum).
+			Int("maxAttempts", maxAttempts).
+			Str("reason", reason).
+			Int64("delayMs", record.DelayMs).
+			Msg("Request attempt failed, retrying")
+
+		if sleepErr := sleepContext(contextOf(ctx), delay); sleepErr != nil {
+			return nil, attempts, sleepErr
+		}
+	}
+}
+
+// attempt sends the request once, parses the response and evaluates the assertions.
+func (n *RequestNode) attempt(
+	ctx ExecutionContext, url string, headers map[string]string, body *requestBody,
+) (*attemptOutcome, error) {
+	resp, respBody, err := n.makeRequestAndReadBody(
+		contextOf(ctx), httpClientOf(ctx), url, n.Data.Method, headers, body, n.Data.Timeout,
+	)
+	if err != nil {
+		return nil, err
+	}
+	_ = resp.Body.Close()
+
+	loggerOf(ctx).Debug().
+		Str("nodeID", n.GetID()).
+		Int("statusCode", resp.StatusCode).
+		Int("bodySize", len(respBody)).
+		Msg("HTTP response received")
+
+	parsedBody := n.parseResponseBody(ctx, resp.Header.Get("Content-Type"), respBody)
+	respCtx := extractors.NewResponseContext(resp, respBody, parsedBody)
+	assertionResults, assertErr := n.runAssertions(ctx, respCtx)
+
+	return &attemptOutcome{
+		headers:          headers,
+		resp:             resp,
+		respBody:         respBody,
+		parsedBody:       parsedBody,
+		respCtx:          respCtx,
+		assertionResults: assertionResults,
+		assertErr:        assertErr,
+	}, nil
+}
+
+// attempts returns the number of attempts allowed by the policy; a nil policy allows one.
+func (p *RetryPolicy) attempts() int {
+	if p == nil || p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// retryReason returns why an attempt should be retried, or an empty string if it should not.
+// Failures caused by the flow's own context are never retried.
+func (p *RetryPolicy) retryReason(
+	parent context.Context, method string, statusCode int, err, assertErr error,
+) string {
+	if p == nil || parent.Err() != nil {
+		return ""
+	}
+
+	if err != nil {
+		class, ok := classifyRequestError(err)
+		if !ok || !slices.Contains(p.retryErrorClasses(), class) {
+			return ""
+		}
+		// A DNS failure means the request was never sent
+		if class != RetryOnDNS && !isIdempotentMethod(method) && !p.RetryUnsafeMethods {
+			return ""
+		}
+		return "error:" + string(class)
+	}
+
+	if slices.Contains(p.retryStatusCodes(), statusCode) {
+		return "status:" + strconv.Itoa(statusCode)
+	}
+	if p.UntilAssertionsPass && assertErr != nil {
+		return "assertions"
+	}
+	return ""
+}
+
+func (p *RetryPolicy) retryStatusCodes() []int {
+	if len(p.RetryOnStatusCodes) == 0 && len(p.RetryOnErrors) == 0 {
+		return defaultRetryStatusCodes
+	}
+	return p.RetryOnStatusCodes
+}
+
+func (p *RetryPolicy) retryErrorClasses() []RetryErrorClass {
+	if len(p.RetryOnStatusCodes) == 0 && len(p.RetryOnErrors) == 0 {
+		return []RetryErrorClass{RetryOnTimeout, RetryOnConnection, RetryOnDNS}
+	}
+	return p.RetryOnErrors
+}
+
+// isIdempotentMethod reports whether sending a request with the method twice has the same effect
+// as sending it once (RFC 9110, section 9.2.2).
+func isIdempotentMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// delay returns the wait before the attempt following the given (1-based) attempt.
+func (b BackoffPolicy) delay(attempt int) time.Duration {
+	delayMs := float64(b.InitialDelayMs)
+	maxDelayMs := float64(b.MaxDelayMs)
+	if b.Type == BackoffExponential {
+		multiplier := b.Multiplier
+		if multiplier <= 0 {
+			multiplier = defaultBackoffMultiplier
+		}
+		delayMs *= math.Pow(multiplier, float64(attempt-1))
+		if maxDelayMs <= 0 {
+			maxDelayMs = math.Max(defaultMaxBackoffDelayMs, float64(b.InitialDelayMs))
+		}
+	}
+	if maxDelayMs > 0 {
+		delayMs = math.Min(delayMs, maxDelayMs)
+	}
+	if b.Jitter > 0 {
+		spread := delayMs * math.Min(b.Jitter, 1)
+		delayMs += (rand.Float64()*2 - 1) * spread //nolint:gosec // jitter does not need a secure source
+	}
+	return time.Duration(math.Max(delayMs, 0)) * time.Millisecond
+}
+
+// classifyRequestError maps a transport error to its retry class.
+func classifyRequestError(err error) (RetryErrorClass, bool) {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return RetryOnDNS, true
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return RetryOnTimeout, true
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return RetryOnConnection, true
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return RetryOnConnection, true
+	}
+	return "", false
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
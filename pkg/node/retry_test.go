@@ -0,0 +1,267 @@
+package node_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/node"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFlakyServer answers with failStatus for the first failures requests, then with 200 and body.
+func newFlakyServer(t *testing.T, failures int32, failStatus int, body string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if calls.Add(1) <= failures {
+					w.WriteHeader(failStatus)
+					_, _ = w.Write([]byte(`{"status": "pending"}`))
+					return
+				}
+				_, _ = w.Write([]byte(body))
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestRequestNode_Retry_TransientStatus(t *testing.T) {
+	server, calls := newFlakyServer(t, 2, http.StatusServiceUnavailable, `{"status": "ok"}`)
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "flaky",
+		"type": "request",
+		"data": {
+			"method": "GET",
+			"url": "`+server.URL+`",
+			"retry": {"maxAttempts": 3, "backoff": {"type": "fixed", "initialDelayMs": 5}}
+		}
+	}`,
+	)
+
+	result, err := reqNode.Execute(node.ExecutionContext{Inputs: map[string]interface{}{}})
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+	reqResult := node.MustAsRequestExecutionResult(result)
+	assert.Equal(t, http.StatusOK, reqResult.ResponseStatusCode)
+	require.Len(t, reqResult.Attempts, 3)
+	assert.Equal(t, http.StatusServiceUnavailable, reqResult.Attempts[0].StatusCode)
+	assert.Equal(t, "status:503", reqResult.Attempts[0].RetryReason)
+	assert.Equal(t, int64(5), reqResult.Attempts[0].DelayMs)
+	assert.Equal(t, 3, reqResult.Attempts[2].Attempt)
+	assert.Empty(t, reqResult.Attempts[2].RetryReason)
+}
+
+func TestRequestNode_Retry_ExhaustsAttempts(t *testing.T) {
+	server, calls := newFlakyServer(t, 10, http.StatusBadGateway, `{}`)
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "down",
+		"type": "request",
+		"assertions": [
+			{"extractor": {"type": "statusCode"}, "operator": {"type": "equals", "expected": 200}}
+		],
+		"data": {
+			"method": "GET",
+			"url": "`+server.URL+`",
+			"retry": {
+				"maxAttempts": 4,
+				"backoff": {"type": "exponential", "initialDelayMs": 2, "multiplier": 3, "maxDelayMs": 10}
+			}
+		}
+	}`,
+	)
+
+	result, err := reqNode.Execute(node.ExecutionContext{Inputs: map[string]interface{}{}})
+
+	var assertionErr *node.AssertionError
+	require.ErrorAs(t, err, &assertionErr)
+	assert.Equal(t, int32(4), calls.Load())
+	reqResult := node.MustAsRequestExecutionResult(result)
+	require.Len(t, reqResult.Attempts, 4)
+	delays := []int64{reqResult.Attempts[0].DelayMs, reqResult.Attempts[1].DelayMs, reqResult.Attempts[2].DelayMs}
+	assert.Equal(t, []int64{2, 6, 10}, delays)
+	assert.Equal(t, http.StatusBadGateway, reqResult.ResponseStatusCode)
+}
+
+func TestRequestNode_Retry_StatusNotListed(t *testing.T) {
+	server, calls := newFlakyServer(t, 1, http.StatusInternalServerError, `{}`)
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "broken",
+		"type": "request",
+		"data": {
+			"method": "GET",
+			"url": "`+server.URL+`",
+			"retry": {"maxAttempts": 3, "retryOnStatusCodes": [503]}
+		}
+	}`,
+	)
+
+	result, err := reqNode.Execute(node.ExecutionContext{Inputs: map[string]interface{}{}})
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Len(t, node.MustAsRequestExecutionResult(result).Attempts, 1)
+}
+
+func TestRequestNode_Retry_UntilAssertionsPass(t *testing.T) {
+	server, calls := newFlakyServer(t, 2, http.StatusOK, `{"status": "done"}`)
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "poll",
+		"type": "request",
+		"assertions": [
+			{"extractor": {"type": "jsonPath", "path": "$.status"}, "operator": {"type": "equals", "expected": "done"}}
+		],
+		"data": {
+			"method": "GET",
+			"url": "`+server.URL+`",
+			"retry": {"maxAttempts": 5, "backoff": {"initialDelayMs": 1}, "untilAssertionsPass": true}
+		}
+	}`,
+	)
+
+	result, err := reqNode.Execute(node.ExecutionContext{Inputs: map[string]interface{}{}})
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+	reqResult := node.MustAsRequestExecutionResult(result)
+	require.Len(t, reqResult.Attempts, 3)
+	assert.Equal(t, "assertions", reqResult.Attempts[0].RetryReason)
+	assert.True(t, reqResult.AssertionResults[0].Passed)
+}
+
+func TestRequestNode_Retry_ConnectionError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "offline",
+		"type": "request",
+		"data": {
+			"method": "GET",
+			"url": "`+url+`",
+			"retry": {"maxAttempts": 2, "retryOnErrors": ["connection"], "backoff": {"initialDelayMs": 1}}
+		}
+	}`,
+	)
+
+	result, err := reqNode.Execute(node.ExecutionContext{Inputs: map[string]interface{}{}})
+
+	require.Error(t, err)
+	reqResult := node.MustAsRequestExecutionResult(result)
+	require.Len(t, reqResult.Attempts, 2)
+	assert.Equal(t, "error:connection", reqResult.Attempts[0].RetryReason)
+	require.NotNil(t, reqResult.Attempts[1].Error)
+	require.NotNil(t, reqResult.ErrorCode)
+	assert.Equal(t, node.ErrorCodeRequestFailed, *reqResult.ErrorCode)
+}
+
+func TestRequestNode_Retry_UnsafeMethods(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	tests := []struct {
+		name             string
+		method           string
+		retry            string
+		expectedAttempts int
+	}{
+		{name: "idempotent method", method: "PUT", retry: `{"maxAttempts": 2}`, expectedAttempts: 2},
+		{name: "unsafe method", method: "POST", retry: `{"maxAttempts": 2}`, expectedAttempts: 1},
+		{
+			name:             "unsafe method opted in",
+			method:           "PATCH",
+			retry:            `{"maxAttempts": 2, "retryUnsafeMethods": true}`,
+			expectedAttempts: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				reqNode := unmarshalRequestNode(
+					t, `{
+					"id": "offline",
+					"type": "request",
+					"data": {"method": "`+tt.method+`", "url": "`+url+`", "retry": `+tt.retry+`}
+				}`,
+				)
+
+				result, err := reqNode.Execute(node.ExecutionContext{Inputs: map[string]interface{}{}})
+
+				require.Error(t, err)
+				assert.Len(t, node.MustAsRequestExecutionResult(result).Attempts, tt.expectedAttempts)
+			},
+		)
+	}
+}
+
+func TestRequestNode_Retry_ExponentialBackoffIsBounded(t *testing.T) {
+	server, _ := newFlakyServer(t, 10, http.StatusServiceUnavailable, `{}`)
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "unbounded",
+		"type": "request",
+		"data": {
+			"method": "GET",
+			"url": "`+server.URL+`",
+			"retry": {"maxAttempts": 5, "backoff": {"type": "exponential", "initialDelayMs": 1, "multiplier": 100000}}
+		}
+	}`,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	result, err := reqNode.Execute(node.ExecutionContext{Context: ctx, Inputs: map[string]interface{}{}})
+
+	require.ErrorIs(t, err, context.Canceled)
+	reqResult := node.MustAsRequestExecutionResult(result)
+	require.Len(t, reqResult.Attempts, 2)
+	assert.Equal(t, int64(1), reqResult.Attempts[0].DelayMs)
+	assert.Equal(t, int64(30000), reqResult.Attempts[1].DelayMs)
+}
+
+func TestRequestNode_Retry_CancelledDuringBackoff(t *testing.T) {
+	server, calls := newFlakyServer(t, 10, http.StatusServiceUnavailable, `{}`)
+	reqNode := unmarshalRequestNode(
+		t, `{
+		"id": "cancelled",
+		"type": "request",
+		"data": {
+			"method": "GET",
+			"url": "`+server.URL+`",
+			"retry": {"maxAttempts": 5, "backoff": {"initialDelayMs": 10000}}
+		}
+	}`,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+
+	result, err := reqNode.Execute(node.ExecutionContext{Context: ctx, Inputs: map[string]interface{}{}})
+
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, int32(1), calls.Load())
+	reqResult := node.MustAsRequestExecutionResult(result)
+	require.NotNil(t, reqResult.ErrorCode)
+	assert.Equal(t, node.ErrorCodeCancelled, *reqResult.ErrorCode)
+	assert.Len(t, reqResult.Attempts, 1)
+}
@@ -1,7 +1,8 @@
 package node
 
 import (
-	"regexp"
+	"maps"
+	"slices"
 	"strings"
 )
 
@@ -13,12 +14,7 @@ func (si *SchemaInference) ExtractTemplateVariables(data interface{}) []string {
 	vars := make(map[string]bool)
 	si.extractVariablesRecursive(data, vars)
 
-	// Convert map to sorted slice
-	result := make([]string, 0, len(vars))
-	for v := range vars {
-		result = append(result, v)
-	}
-	return result
+	return slices.Sorted(maps.Keys(vars))
 }
 
 // extractVariablesRecursive recursively extracts variables from nested structures.
@@ -41,15 +37,12 @@ func (si *SchemaInference) extractVariablesRecursive(data interface{}, vars map[
 	}
 }
 
-// extractVariablesFromString finds all {{variable}} patterns in a string.
+// extractVariablesFromString finds the input references of all {{expression}} templates in a string.
+// Nested paths are reduced to their reference, e.g. {{create-user.body.items[0].id}} needs "create-user.body".
 func (si *SchemaInference) extractVariablesFromString(s string, vars map[string]bool) {
-	// Match {{variable}} patterns
-	re := regexp.MustCompile(`\{\{([^}]+)\}\}`)
-	matches := re.FindAllStringSubmatch(s, -1)
-	for _, match := range matches {
-		if len(match) > 1 {
-			varName := strings.TrimSpace(match[1])
-			vars[varName] = true
+	for _, segment := range parseTemplate(s) {
+		if segment.expr != nil {
+			segment.expr.refs(func(ref string) { vars[ref] = true })
 		}
 	}
 }
@@ -64,12 +57,22 @@ func (si *SchemaInference) InferRequestNodeInputSchema(data RequestData) []strin
 	// Extract from Headers
 	si.extractVariablesRecursive(data.Headers, vars)
 
+	// Extract from PathParams
+	si.extractVariablesRecursive(data.PathParams, vars)
+
 	// Extract from QueryParams
 	si.extractVariablesRecursive(data.QueryParams, vars)
 
 	// Extract from Body
 	si.extractVariablesRecursive(data.Body, vars)
 
+	// Extract from Auth
+	if data.Auth != nil {
+		for _, field := range data.Auth.templateFields() {
+			si.extractVariablesFromString(*field, vars)
+		}
+	}
+
 	// Convert to sorted slice
 	result := make([]string, 0, len(vars))
 	for v := range vars {
@@ -87,6 +90,96 @@ func (si *SchemaInference) InferRequestNodeOutputSchema(outputs []Output) []stri
 	return result
 }
 
+// InferForEachNodeInputSchema infers input schema from the items template and the subflow nodes.
+// References to the item, its index and the outputs of other subflow nodes are provided by the
+// iteration and are not inputs of the forEach node.
+func (si *SchemaInference) InferForEachNodeInputSchema(data ForEachData) []string {
+	vars := make(map[string]bool)
+
+	// Extract from Items
+	si.extractVariablesRecursive(data.Items, vars)
+
+	// Extract from the subflow nodes and declared outputs
+	refs := si.ExtractTemplateVariables(data.Subflow.Outputs)
+	for _, n := range data.Subflow.Nodes {
+		refs = append(refs, n.InputSchema()...)
+	}
+	internal := data.Subflow.nodeIDs()
+	for _, ref := range refs {
+		root, _, _ := strings.Cut(ref, ".")
+		if root == ItemVariable || root == IndexVariable || internal[root] {
+			continue
+		}
+		vars[ref] = true
+	}
+
+	// Convert to sorted slice
+	result := make([]string, 0, len(vars))
+	for v := range vars {
+		result = append(result, v)
+	}
+	slices.Sort(result)
+	return result
+}
+
+// InferConditionNodeInputSchema infers input schema from the values of the branch conditions.
+func (si *SchemaInference) InferConditionNodeInputSchema(data ConditionData) []string {
+	vars := make(map[string]bool)
+
+	// Extract from the condition values
+	for _, branch := range data.Branches {
+		for _, condition := range branch.When {
+			si.extractVariablesRecursive(condition.Value, vars)
+		}
+	}
+
+	// Convert to sorted slice
+	result := make([]string, 0, len(vars))
+	for v := range vars {
+		result = append(result, v)
+	}
+	slices.Sort(result)
+	return result
+}
+
+// InferTransformNodeInputSchema infers input schema from template variables in the mapping values.
+func (si *SchemaInference) InferTransformNodeInputSchema(data TransformData) []string {
+	vars := make(map[string]bool)
+
+	// Extract from the mapping values
+	for _, value := range data.Mapping {
+		si.extractVariablesRecursive(value, vars)
+	}
+
+	// Convert to sorted slice
+	result := make([]string, 0, len(vars))
+	for v := range vars {
+		result = append(result, v)
+	}
+	slices.Sort(result)
+	return result
+}
+
+// InferSubflowNodeInputSchema infers input schema from template variables in the child flow inputs.
+// The child flow only sees these inputs, so the references of its own nodes are not inputs of
+// the subflow node.
+func (si *SchemaInference) InferSubflowNodeInputSchema(data SubflowData) []string {
+	vars := make(map[string]bool)
+
+	// Extract from the child flow inputs
+	for _, value := range data.Inputs {
+		si.extractVariablesRecursive(value, vars)
+	}
+
+	// Convert to sorted slice
+	result := make([]string, 0, len(vars))
+	for v := range vars {
+		result = append(result, v)
+	}
+	slices.Sort(result)
+	return result
+}
+
 // InferDelayNodeInputSchema infers input schema from DelayNode (typically empty or passthrough).
 func (si *SchemaInference) InferDelayNodeInputSchema(_ DelayData) []string {
 	// DelayNode doesn't need inputs
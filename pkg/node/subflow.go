@@ -0,0 +1,82 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/edge"
+)
+
+// Subflow is a group of nodes and edges that a container node, such as forEach, runs as a unit.
+// Its nodes read the inputs of the container node as initial inputs; references to other nodes
+// of the enclosing flow must therefore be declared by the container node.
+type Subflow struct {
+	Nodes []AnyNode   `json:"nodes"`
+	Edges []edge.Edge `json:"edges"`
+	// Outputs declares the outputs of the subflow by public name, like the outputs of a flow.
+	// The container node reads the results of the subflow through them.
+	Outputs map[string]interface{} `json:"outputs,omitempty"`
+}
+
+// UnmarshalJSON unmarshals the nodes of the subflow into their typed nodes.
+func (s *Subflow) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Nodes   []json.RawMessage      `json:"nodes"`
+		Edges   []edge.Edge            `json:"edges"`
+		Outputs map[string]interface{} `json:"outputs"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	nodes := make([]AnyNode, len(raw.Nodes))
+	for i, rawNode := range raw.Nodes {
+		typedNode, err := UnmarshalNode(rawNode)
+		if err != nil {
+			return fmt.Errorf("subflow node #%d: %w", i, err)
+		}
+		nodes[i] = typedNode
+	}
+
+	s.Nodes = nodes
+	s.Edges = raw.Edges
+	s.Outputs = raw.Outputs
+	return nil
+}
+
+// nodeIDs returns the set of the IDs of the subflow nodes.
+func (s *Subflow) nodeIDs() map[string]bool {
+	ids := make(map[string]bool, len(s.Nodes))
+	for _, n := range s.Nodes {
+		ids[n.GetID()] = true
+	}
+	return ids
+}
+
+// Container is implemented by nodes that run a subflow; GetSubflow returns nil when the node
+// runs a referenced flow instead, see Referrer. The engine prepares the subflow when
+// the flow is loaded, and hands a SubflowRunner to the node through its ExecutionContext.
+type Container interface {
+	GetSubflow() *Subflow
+}
+
+// FlowReference designates a flow registered with the engine by its name and version.
+type FlowReference struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+func (r FlowReference) String() string {
+	return fmt.Sprintf("'%s' version '%s'", r.Name, r.Version)
+}
+
+// Referrer is implemented by nodes that run a flow registered with the engine instead of an
+// inline subflow. The engine resolves the reference when the flow is loaded.
+type Referrer interface {
+	GetFlowReference() *FlowReference
+}
+
+// SubflowRunner runs the subflow of a container node with the given initial inputs.
+// The run shares the HTTP client, token cache and redactor of the enclosing flow run.
+type SubflowRunner func(ctx context.Context, inputs map[string]interface{}) (*FlowExecutionResult, error)
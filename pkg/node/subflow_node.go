@@ -0,0 +1,181 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+type SubflowData struct {
+	// Ref designates a flow registered with the engine. Exactly one of Ref and Flow must be set.
+	Ref *FlowReference `json:"ref,omitempty"`
+	// Flow embeds the child flow.
+	Flow *Subflow `json:"flow,omitempty"`
+	// Inputs are the initial inputs of the child flow. Values usually are templates referencing
+	// flow inputs or upstream outputs, e.g. {"username": "{{credentials.username}}"}.
+	Inputs map[string]interface{} `json:"inputs,omitempty"`
+	// Outputs maps the outputs of the node to outputs the child flow declares, e.g. {"token": "accessToken"}.
+	Outputs map[string]string `json:"outputs,omitempty"`
+}
+
+// SubflowNode runs another flow as a single step, so that common sequences such as a login
+// can be defined once and reused. The child flow only sees the inputs given by the node.
+type SubflowNode struct {
+	BaseNode
+
+	Data SubflowData `json:"data"`
+}
+
+// AsSubflowNode safely casts an AnyNode to a SubflowNode
+// Returns the SubflowNode and true if the cast succeeds, nil and false otherwise.
+func AsSubflowNode(node AnyNode) (*SubflowNode, bool) {
+	subflowNode, ok := node.(*SubflowNode)
+	return subflowNode, ok
+}
+
+// MustAsSubflowNode casts an AnyNode to a SubflowNode, panicking if it fails
+// Use this when you're certain the node is a SubflowNode.
+func MustAsSubflowNode(node AnyNode) *SubflowNode {
+	subflowNode, ok := AsSubflowNode(node)
+	if !ok {
+		panic("expected SubflowNode but got different type")
+	}
+	return subflowNode
+}
+
+func (n *SubflowNode) GetData() SubflowData {
+	return n.Data
+}
+
+// GetSubflow returns the embedded child flow, or nil when the node references a flow.
+func (n *SubflowNode) GetSubflow() *Subflow {
+	return n.Data.Flow
+}
+
+// GetFlowReference returns the referenced child flow, or nil when the node embeds a flow.
+func (n *SubflowNode) GetFlowReference() *FlowReference {
+	return n.Data.Ref
+}
+
+// InputSchema infers inputs from template variables in the child flow inputs.
+func (n *SubflowNode) InputSchema() []string {
+	si := &SchemaInference{}
+	return si.InferSubflowNodeInputSchema(n.Data)
+}
+
+// OutputSchema returns the names of the exposed child outputs, sorted.
+func (n *SubflowNode) OutputSchema() []string {
+	outputs := make([]string, 0, len(n.Data.Outputs))
+	for name := range n.Data.Outputs {
+		outputs = append(outputs, name)
+	}
+	slices.Sort(outputs)
+	return outputs
+}
+
+// Execute runs the child flow and exposes the selected child outputs.
+func (n *SubflowNode) Execute(ctx ExecutionContext) (AnyExecutionResult, error) {
+	startTime := time.Now()
+
+	loggerOf(ctx).Debug().
+		Str("nodeID", n.GetID()).
+		Any("inputs", ctx.Inputs).
+		Msg("Starting subflow node execution")
+
+	childInputs, err := n.resolveInputs(ctx)
+	if err != nil {
+		return n.createResult(ctx.Inputs, nil, err, startTime), err
+	}
+	if ctx.RunSubflow == nil {
+		err = errors.New("subflow node needs a subflow runner, run it through the flow engine")
+		return n.createResult(ctx.Inputs, nil, err, startTime), err
+	}
+
+	childResult, err := ctx.RunSubflow(contextOf(ctx), childInputs)
+	if err != nil {
+		err = fmt.Errorf("subflow failed: %w", err)
+		loggerOf(ctx).Error().
+			Str("nodeID", n.GetID()).
+			Err(err).
+			Msg("Subflow node failed")
+		return n.createResult(ctx.Inputs, childResult, err, startTime), err
+	}
+
+	outputs := make(map[string]interface{}, len(n.Data.Outputs))
+	for _, name := range n.OutputSchema() {
+		childOutput := n.Data.Outputs[name]
+		value, exists := childResult.Output(childOutput)
+		if !exists {
+			err = fmt.Errorf("subflow did not produce output '%s' exposed as '%s'", childOutput, name)
+			return n.createResult(ctx.Inputs, childResult, err, startTime), err
+		}
+		outputs[name] = value
+	}
+
+	result := n.createResult(ctx.Inputs, childResult, nil, startTime)
+	result.Outputs = outputs
+
+	loggerOf(ctx).Info().
+		Str("nodeID", n.GetID()).
+		Int("outputCount", len(outputs)).
+		Int64("durationMs", result.DurationMs).
+		Msg("Subflow node executed successfully")
+
+	return result, nil
+}
+
+// resolveInputs resolves the templates of the child flow inputs.
+func (n *SubflowNode) resolveInputs(ctx ExecutionContext) (map[string]interface{}, error) {
+	resolver := newTemplateResolver(ctx)
+	inputs := make(map[string]interface{}, len(n.Data.Inputs))
+	for name, value := range n.Data.Inputs {
+		resolved, err := resolver.ResolveAt(value, "inputs."+name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve input '%s': %w", name, err)
+		}
+		inputs[name] = resolved
+	}
+	if err := checkUnresolvedTemplates(n.GetID(), resolver, ctx); err != nil {
+		return nil, err
+	}
+	return inputs, nil
+}
+
+func (n *SubflowNode) createResult(
+	inputs map[string]interface{},
+	childResult *FlowExecutionResult,
+	err error,
+	startTime time.Time,
+) *SubflowExecutionResult {
+	result := &SubflowExecutionResult{
+		BaseExecutionResult: BaseExecutionResult{
+			NodeID:      n.GetID(),
+			DisplayName: n.GetDisplayName(),
+			NodeType:    TypeSubflow,
+			Inputs:      inputs,
+			ExecutedAt:  time.Now(),
+		},
+		Flow:       n.Data.Ref,
+		Result:     childResult,
+		DurationMs: time.Since(startTime).Milliseconds(),
+	}
+	if err != nil {
+		errMsg := err.Error()
+		errCode := ErrorCodeSubflowFailed
+		var unresolvedErr *UnresolvedTemplateError
+		switch {
+		case errors.As(err, &unresolvedErr):
+			errCode = ErrorCodeUnresolvedTemplate
+		case errors.Is(err, context.Canceled):
+			errCode = ErrorCodeCancelled
+		case errors.Is(err, context.DeadlineExceeded):
+			errCode = ErrorCodeTimeout
+		}
+		result.Error = err
+		result.ErrorMsg = &errMsg
+		result.ErrorCode = &errCode
+	}
+	return result
+}
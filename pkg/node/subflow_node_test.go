@@ -0,0 +1,97 @@
+package node_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/node"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubflowNode_Execute(t *testing.T) {
+	parsed, err := node.UnmarshalNode(
+		[]byte(`{
+		"id": "authenticate",
+		"type": "subflow",
+		"data": {
+			"ref": {"name": "Login", "version": "2"},
+			"inputs": {"username": "{{credentials.username}}", "tenant": "{{get-tenant.id}}", "remember": true},
+			"outputs": {"token": "accessToken", "expiresIn": "expiresIn"}
+		}
+	}`),
+	)
+	require.NoError(t, err)
+	subflowNode := node.MustAsSubflowNode(parsed)
+	assert.Equal(t, []string{"credentials.username", "get-tenant.id"}, subflowNode.InputSchema())
+	assert.Equal(t, []string{"expiresIn", "token"}, subflowNode.OutputSchema())
+	assert.Equal(t, &node.FlowReference{Name: "Login", Version: "2"}, subflowNode.GetFlowReference())
+
+	var childInputs map[string]interface{}
+	result, err := subflowNode.Execute(
+		node.ExecutionContext{
+			Inputs: map[string]interface{}{"credentials.username": "alice", "get-tenant.id": float64(7)},
+			RunSubflow: func(_ context.Context, inputs map[string]interface{}) (*node.FlowExecutionResult, error) {
+				childInputs = inputs
+				return &node.FlowExecutionResult{
+					Outputs:      map[string]interface{}{"accessToken": "tok-1", "expiresIn": float64(3600)},
+					FinalOutputs: map[string]interface{}{"login.token": "tok-1"},
+					Success:      true,
+				}, nil
+			},
+		},
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"username": "alice", "tenant": float64(7), "remember": true}, childInputs)
+	assert.Equal(t, map[string]interface{}{"token": "tok-1", "expiresIn": float64(3600)}, result.GetOutputs())
+	subflowResult := node.MustAsSubflowExecutionResult(result)
+	assert.Equal(t, "Login", subflowResult.Flow.Name)
+	assert.True(t, subflowResult.Result.Success)
+}
+
+func TestSubflowNode_Execute_Failures(t *testing.T) {
+	tests := []struct {
+		name        string
+		runner      node.SubflowRunner
+		expectedErr string
+	}{
+		{
+			name: "child flow fails",
+			runner: func(_ context.Context, _ map[string]interface{}) (*node.FlowExecutionResult, error) {
+				err := errors.New("node login failed")
+				return &node.FlowExecutionResult{Error: err}, err
+			},
+			expectedErr: "subflow failed: node login failed",
+		},
+		{
+			name: "missing child output",
+			runner: func(_ context.Context, _ map[string]interface{}) (*node.FlowExecutionResult, error) {
+				return &node.FlowExecutionResult{
+					FinalOutputs: map[string]interface{}{"login.token": "tok-1"}, Success: true,
+				}, nil
+			},
+			expectedErr: "subflow did not produce output 'token' exposed as 'token'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				subflowNode := &node.SubflowNode{
+					BaseNode: node.BaseNode{ID: "authenticate", NodeType: node.TypeSubflow},
+					Data:     node.SubflowData{Outputs: map[string]string{"token": "token"}},
+				}
+
+				result, err := subflowNode.Execute(node.ExecutionContext{RunSubflow: tt.runner})
+
+				require.Error(t, err)
+				assert.Equal(t, tt.expectedErr, err.Error())
+				subflowResult := node.MustAsSubflowExecutionResult(result)
+				assert.Equal(t, node.ErrorCodeSubflowFailed, *subflowResult.ErrorCode)
+				assert.NotNil(t, subflowResult.Result)
+			},
+		)
+	}
+}
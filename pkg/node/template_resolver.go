@@ -1,93 +1,269 @@
 package node
 
 import (
+	"cmp"
 	"encoding/json"
 	"fmt"
-	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
-// TemplateResolver handles resolution of {{variableName}} templates in strings and objects.
+// TemplateResolver handles resolution of {{expression}} templates in strings and objects.
+// See expression.go for the supported expression syntax.
+// Templates referencing variables that cannot be resolved are left in place and recorded,
+// see Unresolved.
 type TemplateResolver struct {
-	variables map[string]interface{}
+	variables  map[string]interface{}
+	allOutputs map[string]map[string]interface{}
+	unresolved []UnresolvedReference
+	logger     *zerolog.Logger
+}
+
+// UnresolvedReference is a template variable that could not be resolved.
+type UnresolvedReference struct {
+	Variable string `json:"variable"`
+	// Location is url, header:<name>, path:<name>, query:<name>, auth.<field>, a body path such as body.user.id,
+	// items (forEach) or branch:<name>.when[<index>] (condition)
+	Location string `json:"location"`
+}
+
+// UnresolvedTemplateError is returned when a node's templates reference variables that cannot be resolved.
+type UnresolvedTemplateError struct {
+	NodeID     string
+	References []UnresolvedReference
+}
+
+func (e *UnresolvedTemplateError) Error() string {
+	references := make([]string, 0, len(e.References))
+	for _, ref := range e.References {
+		references = append(references, fmt.Sprintf("%s (%s)", ref.Variable, ref.Location))
+	}
+	message := fmt.Sprintf("%d unresolved template variable(s): %s", len(e.References), strings.Join(references, ", "))
+	if e.NodeID == "" {
+		return message
+	}
+	return fmt.Sprintf("node %s: %s", e.NodeID, message)
+}
+
+// checkUnresolvedTemplates returns an UnresolvedTemplateError listing the references the resolver
+// could not resolve. When the execution allows unresolved templates, they are only logged.
+func checkUnresolvedTemplates(nodeID string, resolver *TemplateResolver, ctx ExecutionContext) error {
+	refs := resolver.Unresolved()
+	if len(refs) == 0 {
+		return nil
+	}
+	err := &UnresolvedTemplateError{NodeID: nodeID, References: refs}
+	if ctx.LenientTemplates {
+		loggerOf(ctx).Warn().
+			Str("nodeID", nodeID).
+			Err(err).
+			Msg("Unresolved templates left in place")
+		return nil
+	}
+	loggerOf(ctx).Error().
+		Str("nodeID", nodeID).
+		Err(err).
+		Msg("Template resolution failed")
+	return err
 }
 
 // NewTemplateResolver creates a new template resolver with the given variables.
 func NewTemplateResolver(variables map[string]interface{}) *TemplateResolver {
 	return &TemplateResolver{
 		variables: variables,
+		logger:    &log.Logger,
 	}
 }
 
-// Resolve recursively resolves all {{variableName}} templates in the given value
+// newTemplateResolver creates the resolver for the templates of a node. Expressions may read any
+// output produced so far, not only the declared inputs, and the resolver logs through the run's logger.
+func newTemplateResolver(ctx ExecutionContext) *TemplateResolver {
+	return NewTemplateResolver(ctx.Inputs).WithAllOutputs(ctx.AllOutputs).WithLogger(loggerOf(ctx))
+}
+
+// WithAllOutputs lets expressions fall back to outputs that were not declared as inputs,
+// such as the optional first argument of default(). Keys are node IDs, "" holds the initial inputs.
+func (tr *TemplateResolver) WithAllOutputs(allOutputs map[string]map[string]interface{}) *TemplateResolver {
+	tr.allOutputs = allOutputs
+	return tr
+}
+
+// WithLogger makes the resolver log through logger, such as the logger of a run whose output masks
+// its secrets. Resolved values are logged at debug level.
+func (tr *TemplateResolver) WithLogger(logger *zerolog.Logger) *TemplateResolver {
+	tr.logger = logger
+	return tr
+}
+
+// Unresolved returns every unresolved reference met so far, ordered by location and variable.
+func (tr *TemplateResolver) Unresolved() []UnresolvedReference {
+	refs := slices.Clone(tr.unresolved)
+	slices.SortFunc(
+		refs, func(a, b UnresolvedReference) int {
+			return cmp.Or(cmp.Compare(a.Location, b.Location), cmp.Compare(a.Variable, b.Variable))
+		},
+	)
+	return refs
+}
+
+// Resolve recursively resolves all {{expression}} templates in the given value
 // Supports strings, maps, slices, and nested structures.
+// A string consisting of exactly one template is replaced by the typed value of the expression.
 func (tr *TemplateResolver) Resolve(value interface{}) (interface{}, error) {
-	log.Debug().
+	return tr.ResolveAt(value, "")
+}
+
+// ResolveAt is like Resolve; location names the resolved value in unresolved references
+// (e.g. "body"), nested values extend it with their key or index.
+func (tr *TemplateResolver) ResolveAt(value interface{}, location string) (interface{}, error) {
+	tr.logger.Debug().
 		Any("value", value).
 		Msg("Resolving template")
 
 	switch v := value.(type) {
 	case string:
-		resolved := tr.resolveString(v)
-		log.Debug().
+		resolved, err := tr.resolveValue(v, location)
+		if err != nil {
+			return nil, err
+		}
+		tr.logger.Debug().
 			Str("original", v).
-			Str("resolved", resolved).
+			Any("resolved", resolved).
 			Msg("String template resolved")
 		return resolved, nil
 	case map[string]interface{}:
-		return tr.resolveMap(v)
+		return tr.resolveMap(v, location)
 	case []interface{}:
-		return tr.resolveSlice(v)
+		return tr.resolveSlice(v, location)
 	case json.RawMessage:
 		// Handle JSON raw messages
-		log.Debug().
+		tr.logger.Debug().
 			Msg("Resolving JSON raw message")
 		var unmarshalled interface{}
 		if err := json.Unmarshal(v, &unmarshalled); err != nil {
-			log.Error().
+			tr.logger.Error().
 				Err(err).
 				Msg("Failed to unmarshal JSON raw message")
 			return nil, err
 		}
-		return tr.Resolve(unmarshalled)
+		return tr.ResolveAt(unmarshalled, location)
 	default:
 		return v, nil
 	}
 }
 
-// resolveString replaces all {{variableName}} patterns with their values.
-func (tr *TemplateResolver) resolveString(s string) string {
-	// Pattern: {{variableName}}
-	pattern := regexp.MustCompile(`\{\{([^}]+)\}\}`)
+// ResolveString resolves all templates in s and always returns a string.
+func (tr *TemplateResolver) ResolveString(s string) (string, error) {
+	return tr.ResolveStringAt(s, "")
+}
+
+// ResolveStringAt is like ResolveString; location names s in unresolved references (e.g. "url").
+func (tr *TemplateResolver) ResolveStringAt(s, location string) (string, error) {
+	return tr.renderSegments(parseTemplate(s), location)
+}
+
+// resolveValue resolves s, preserving the type of the value if s is exactly one template.
+func (tr *TemplateResolver) resolveValue(s, location string) (interface{}, error) {
+	segments := parseTemplate(s)
+	if len(segments) != 1 || segments[0].expr == nil {
+		return tr.renderSegments(segments, location)
+	}
+
+	value, err := segments[0].expr.eval(tr)
+	if variable, missing := isVariableNotFound(err); missing {
+		// Leave unresolved templates untouched
+		tr.recordUnresolved(variable, location)
+		return s, nil
+	}
+	return value, err
+}
+
+func (tr *TemplateResolver) renderSegments(segments []templateSegment, location string) (string, error) {
+	var sb strings.Builder
+	for _, segment := range segments {
+		if segment.expr == nil {
+			sb.WriteString(segment.literal)
+			continue
+		}
+		value, err := segment.expr.eval(tr)
+		if variable, missing := isVariableNotFound(err); missing {
+			// Leave unresolved templates untouched
+			tr.recordUnresolved(variable, location)
+			sb.WriteString(templateOpen + segment.source + templateClose)
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(formatTemplateValue(value))
+	}
+	return sb.String(), nil
+}
+
+func (tr *TemplateResolver) recordUnresolved(variable, location string) {
+	tr.logger.Debug().
+		Str("variable", variable).
+		Str("location", location).
+		Msg("Template variable could not be resolved")
+	tr.unresolved = append(tr.unresolved, UnresolvedReference{Variable: variable, Location: location})
+}
+
+// lookup resolves the root of a path against the variables, then against all outputs.
+// Variable keys may contain dots ("create-user.body"); the longest matching key wins
+// and the rest of the path is navigated inside its value.
+func (tr *TemplateResolver) lookup(path pathExpr) (interface{}, error) {
+	if value, rest, found := lookupLongestKey(tr.variables, path); found {
+		return path.navigate(value, rest)
+	}
 
-	result := pattern.ReplaceAllStringFunc(
-		s, func(match string) string {
-			// Extract variable name from {{varName}}
-			varName := match[2 : len(match)-2]
-			if val, exists := tr.variables[varName]; exists {
-				return fmt.Sprintf("%v", val)
+	if len(path.parts) > 1 && !path.parts[1].isIndex {
+		if outputs, exists := tr.allOutputs[path.parts[0].key]; exists {
+			if value, found := outputs[path.parts[1].key]; found {
+				return path.navigate(value, path.parts[2:])
 			}
-			return match
-		},
-	)
+		}
+	}
+	if value, rest, found := lookupLongestKey(tr.allOutputs[""], path); found {
+		return path.navigate(value, rest)
+	}
+
+	return nil, &variableNotFoundError{variable: path.String()}
+}
 
-	return result
+// lookupLongestKey finds the longest dotted prefix of path that is a key of values.
+func lookupLongestKey(values map[string]interface{}, path pathExpr) (interface{}, []pathPart, bool) {
+	var keys []string
+	for _, part := range path.parts {
+		if part.isIndex {
+			break
+		}
+		keys = append(keys, part.key)
+	}
+	for count := len(keys); count > 0; count-- {
+		if value, exists := values[strings.Join(keys[:count], ".")]; exists {
+			return value, path.parts[count:], true
+		}
+	}
+	return nil, nil, false
 }
 
 // resolveMap recursively resolves templates in all map values.
-func (tr *TemplateResolver) resolveMap(m map[string]interface{}) (map[string]interface{}, error) {
-	log.Debug().
+func (tr *TemplateResolver) resolveMap(m map[string]interface{}, location string) (map[string]interface{}, error) {
+	tr.logger.Debug().
 		Int("mapSize", len(m)).
 		Msg("Resolving map templates")
 
 	resolved := make(map[string]interface{})
 
 	for key, val := range m {
-		resolvedVal, err := tr.Resolve(val)
+		resolvedVal, err := tr.ResolveAt(val, joinLocation(location, key))
 		if err != nil {
 			err = fmt.Errorf("error resolving value for key '%s': %w", key, err)
-			log.Error().
+			tr.logger.Error().
 				Str("key", key).
 				Err(err).
 				Msg("Failed to resolve map value")
@@ -96,7 +272,7 @@ func (tr *TemplateResolver) resolveMap(m map[string]interface{}) (map[string]int
 		resolved[key] = resolvedVal
 	}
 
-	log.Debug().
+	tr.logger.Debug().
 		Int("mapSize", len(resolved)).
 		Msg("Map templates resolved")
 
@@ -104,18 +280,18 @@ func (tr *TemplateResolver) resolveMap(m map[string]interface{}) (map[string]int
 }
 
 // resolveSlice recursively resolves templates in all slice elements.
-func (tr *TemplateResolver) resolveSlice(s []interface{}) ([]interface{}, error) {
-	log.Debug().
+func (tr *TemplateResolver) resolveSlice(s []interface{}, location string) ([]interface{}, error) {
+	tr.logger.Debug().
 		Int("sliceSize", len(s)).
 		Msg("Resolving slice templates")
 
 	resolved := make([]interface{}, len(s))
 
 	for i, val := range s {
-		resolvedVal, err := tr.Resolve(val)
+		resolvedVal, err := tr.ResolveAt(val, location+"["+strconv.Itoa(i)+"]")
 		if err != nil {
 			err = fmt.Errorf("error resolving element at index %d: %w", i, err)
-			log.Error().
+			tr.logger.Error().
 				Int("index", i).
 				Err(err).
 				Msg("Failed to resolve slice element")
@@ -124,34 +300,52 @@ func (tr *TemplateResolver) resolveSlice(s []interface{}) ([]interface{}, error)
 		resolved[i] = resolvedVal
 	}
 
-	log.Debug().
+	tr.logger.Debug().
 		Int("sliceSize", len(resolved)).
 		Msg("Slice templates resolved")
 
 	return resolved, nil
 }
 
+// joinLocation appends a map key to a location path.
+func joinLocation(location, key string) string {
+	if location == "" {
+		return key
+	}
+	return location + "." + key
+}
+
 // ResolveTemplatesInRequest is a convenience function for RequestNode to resolve templates.
+// It returns an UnresolvedTemplateError when a referenced variable is missing from inputs.
 func ResolveTemplatesInRequest(
 	url string, headers map[string]string, body interface{}, inputs map[string]interface{},
 ) (string, map[string]string, interface{}, error) {
 	resolver := NewTemplateResolver(inputs)
 
 	// Resolve URL
-	resolvedURL := resolver.resolveString(url)
+	resolvedURL, err := resolver.ResolveStringAt(url, "url")
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("error resolving URL: %w", err)
+	}
 
 	// Resolve headers
 	resolvedHeaders := make(map[string]string)
 	for key, headerVal := range headers {
-		resolved := resolver.resolveString(headerVal)
+		resolved, headerErr := resolver.ResolveStringAt(headerVal, "header:"+key)
+		if headerErr != nil {
+			return "", nil, nil, fmt.Errorf("error resolving header '%s': %w", key, headerErr)
+		}
 		resolvedHeaders[key] = resolved
 	}
 
 	// Resolve body
-	resolvedBody, err := resolver.Resolve(body)
+	resolvedBody, err := resolver.ResolveAt(body, "body")
 	if err != nil {
 		return "", nil, nil, fmt.Errorf("error resolving body: %w", err)
 	}
 
+	if refs := resolver.Unresolved(); len(refs) > 0 {
+		return "", nil, nil, &UnresolvedTemplateError{References: refs}
+	}
 	return resolvedURL, resolvedHeaders, resolvedBody, nil
 }
@@ -0,0 +1,287 @@
+package node_test
+
+import (
+	"encoding/base64"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/node"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestResolver() *node.TemplateResolver {
+	return node.NewTemplateResolver(
+		map[string]interface{}{
+			"userId": "user-123",
+			"count":  float64(3),
+			"create-user.body": map[string]interface{}{
+				"items": []interface{}{
+					map[string]interface{}{"id": "item-1", "price": 9.5},
+					map[string]interface{}{"id": "item-2", "price": float64(20)},
+				},
+			},
+			"user.name": "alice",
+		},
+	)
+}
+
+func TestTemplateResolver_TypedSubstitution(t *testing.T) {
+	resolver := newTestResolver()
+
+	resolved, err := resolver.Resolve(
+		map[string]interface{}{
+			"count":  "{{count}}",
+			"items":  "{{ create-user.body.items }}",
+			"first":  "{{create-user.body.items[0]}}",
+			"flag":   "{{true}}",
+			"answer": "{{42}}",
+		},
+	)
+
+	require.NoError(t, err)
+	body, ok := resolved.(map[string]interface{})
+	require.True(t, ok)
+	assert.InDelta(t, 3.0, body["count"], 0)
+	assert.Len(t, body["items"], 2)
+	assert.Equal(t, map[string]interface{}{"id": "item-1", "price": 9.5}, body["first"])
+	assert.Equal(t, true, body["flag"])
+	assert.Equal(t, 42, body["answer"])
+}
+
+func TestTemplateResolver_EmbeddedFormatting(t *testing.T) {
+	resolver := newTestResolver()
+
+	resolved, err := resolver.ResolveString(
+		"id={{create-user.body.items[1].id}} price={{create-user.body.items[1].price}} " +
+			"count={{count}} first={{create-user.body.items[0]}}",
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, `id=item-2 price=20 count=3 first={"id":"item-1","price":9.5}`, resolved)
+}
+
+func TestTemplateResolver_Functions(t *testing.T) {
+	resolver := newTestResolver()
+
+	tests := []struct {
+		template string
+		expected interface{}
+	}{
+		{`{{upper(user.name)}}`, "ALICE"},
+		{`{{user.name | upper}}`, "ALICE"},
+		{`{{ "  Bob " | trim | lower }}`, "bob"},
+		{`{{base64("user:pass")}}`, base64.StdEncoding.EncodeToString([]byte("user:pass"))},
+		{`{{base64("user:pass") | base64Decode}}`, "user:pass"},
+		{`{{default(user.nickname, "anonymous")}}`, "anonymous"},
+		{`{{user.nickname | default("guest")}}`, "guest"},
+		{`{{default(userId, "anonymous")}}`, "user-123"},
+		{`{{json(create-user.body.items[0])}}`, `{"id":"item-1","price":9.5}`},
+		{`Hello {{upper('x')}}!`, "Hello X!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.template, func(t *testing.T) {
+				resolved, err := resolver.Resolve(tt.template)
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, resolved)
+			},
+		)
+	}
+}
+
+func TestTemplateResolver_CollectionFunctions(t *testing.T) {
+	resolver := node.NewTemplateResolver(
+		map[string]interface{}{
+			"ids": []interface{}{"a", "b", "c"},
+			"items": []interface{}{
+				map[string]interface{}{"price": 9.5, "updatedAt": "2024-03-01T10:00:00Z"},
+				map[string]interface{}{"price": float64(20), "updatedAt": "2024-05-12T08:30:00Z"},
+				map[string]interface{}{"updatedAt": "2024-01-20T17:45:00Z"},
+			},
+			"totals":    []interface{}{9.5, float64(20)},
+			"user.name": "alice",
+		},
+	)
+
+	tests := []struct {
+		template string
+		expected interface{}
+	}{
+		{`{{join(ids)}}`, "a,b,c"},
+		{`{{ids | join(" | ")}}`, "a | b | c"},
+		{`{{length(ids)}}`, 3},
+		{`{{length(user.name)}}`, 5},
+		{`{{concat(ids, ids)}}`, []interface{}{"a", "b", "c", "a", "b", "c"}},
+		{`{{concat("user-", user.name)}}`, "user-alice"},
+		{`{{pluck(items, "price")}}`, []interface{}{9.5, float64(20), nil}},
+		{`{{sum(totals)}}`, 29.5},
+		{`{{sum(1, 2.5, "3")}}`, 6.5},
+		{`{{min(4, 2, 8)}}`, float64(2)},
+		{`{{max(pluck(items, "updatedAt"))}}`, "2024-05-12T08:30:00Z"},
+		{`{{min(pluck(items, "updatedAt"))}}`, "2024-01-20T17:45:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.template, func(t *testing.T) {
+				resolved, err := resolver.Resolve(tt.template)
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, resolved)
+			},
+		)
+	}
+}
+
+func TestTemplateResolver_GeneratedValues(t *testing.T) {
+	resolver := newTestResolver()
+
+	id, err := resolver.Resolve("{{uuid()}}")
+	require.NoError(t, err)
+	idString, ok := id.(string)
+	require.True(t, ok)
+	_, err = uuid.Parse(idString)
+	require.NoError(t, err)
+
+	now, err := resolver.Resolve("{{now()}}")
+	require.NoError(t, err)
+	nowString, ok := now.(string)
+	require.True(t, ok)
+	_, err = time.Parse(time.RFC3339, nowString)
+	require.NoError(t, err)
+
+	unix, err := resolver.Resolve(`{{now("unix")}}`)
+	require.NoError(t, err)
+	assert.IsType(t, int64(0), unix)
+
+	for range 20 {
+		value, randomErr := resolver.Resolve("{{randomInt(1, 3)}}")
+		require.NoError(t, randomErr)
+		assert.Contains(t, []interface{}{1, 2, 3}, value)
+	}
+
+	wide, err := resolver.Resolve("{{randomInt(-9223372036854775808, 9223372036854775807)}}")
+	require.NoError(t, err)
+	assert.IsType(t, 0, wide)
+
+	single, err := resolver.Resolve("{{randomInt(9223372036854775807, 9223372036854775807)}}")
+	require.NoError(t, err)
+	assert.Equal(t, math.MaxInt, single)
+}
+
+func TestTemplateResolver_LiteralBraces(t *testing.T) {
+	resolver := newTestResolver()
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{
+			name:     "handlebars body",
+			template: "{{#each items}}<li>{{this}}</li>{{/each}} for {{userId}}",
+			expected: "{{#each items}}<li>{{this}}</li>{{/each}} for user-123",
+		},
+		{name: "trailing pipe", template: "{{user.name | }}", expected: "{{user.name | }}"},
+		{name: "operator", template: "{{ 'a' + 'b' }}", expected: "{{ 'a' + 'b' }}"},
+		{name: "unclosed index", template: "{{items[}}", expected: "{{items[}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				resolved, err := resolver.Resolve(tt.template)
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, resolved)
+			},
+		)
+	}
+}
+
+func TestTemplateResolver_UnresolvedVariablesAreKept(t *testing.T) {
+	resolver := newTestResolver()
+
+	resolved, err := resolver.Resolve("{{missing}}")
+	require.NoError(t, err)
+	assert.Equal(t, "{{missing}}", resolved)
+
+	resolvedString, err := resolver.ResolveString("/users/{{userId}}/{{create-user.body.items[5].id}}")
+	require.NoError(t, err)
+	assert.Equal(t, "/users/user-123/{{create-user.body.items[5].id}}", resolvedString)
+}
+
+func TestResolveTemplatesInRequest_Unresolved(t *testing.T) {
+	url, _, _, err := node.ResolveTemplatesInRequest(
+		"/users/{{userId}}", map[string]string{"Authorization": "Bearer {{token}}"},
+		map[string]interface{}{"id": "{{missing}}"}, map[string]interface{}{"userId": "user-123"},
+	)
+
+	assert.Empty(t, url)
+	var unresolvedErr *node.UnresolvedTemplateError
+	require.ErrorAs(t, err, &unresolvedErr)
+	assert.Equal(
+		t, "2 unresolved template variable(s): missing (body.id), token (header:Authorization)", err.Error(),
+	)
+}
+
+func TestTemplateResolver_AllOutputsFallback(t *testing.T) {
+	resolver := node.NewTemplateResolver(map[string]interface{}{}).WithAllOutputs(
+		map[string]map[string]interface{}{
+			"":           {"env": "staging"},
+			"get-config": {"body": map[string]interface{}{"region": "eu"}},
+		},
+	)
+
+	resolved, err := resolver.ResolveString(`{{default(get-config.body.region, "us")}}-{{env}}`)
+
+	require.NoError(t, err)
+	assert.Equal(t, "eu-staging", resolved)
+}
+
+func TestTemplateResolver_Errors(t *testing.T) {
+	resolver := newTestResolver()
+
+	tests := []struct {
+		template string
+		message  string
+	}{
+		{"{{unknownFn()}}", "unknown template function 'unknownFn'"},
+		{"{{upper()}}", "expected 1 argument(s), got 0"},
+		{"{{randomInt(5, 1)}}", "max 1 is lower than min 5"},
+		{`{{sum("a", 1)}}`, `expected a number, got "a"`},
+		{`{{max(1, "b")}}`, `expected a number, got "b"`},
+		{`{{max("a", 1)}}`, "cannot compare int with a string"},
+		{`{{join(userId)}}`, "expected an array, got string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.template, func(t *testing.T) {
+				_, err := resolver.Resolve(tt.template)
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.message)
+			},
+		)
+	}
+}
+
+func TestSchemaInference_ExpressionReferences(t *testing.T) {
+	si := &node.SchemaInference{}
+
+	vars := si.ExtractTemplateVariables(
+		map[string]interface{}{
+			"id":       "{{create-user.body.items[0].id}}",
+			"name":     "{{ user.name | upper }}",
+			"nickname": `{{default(profile.nickname, userId)}}`,
+			"request":  "{{uuid()}}-{{ tenant }}",
+			"list":     "{{tags[0]}}",
+		},
+	)
+
+	assert.ElementsMatch(
+		t, []string{"create-user.body", "user.name", "userId", "tenant", "tags"}, vars,
+	)
+}
@@ -0,0 +1,127 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+type TransformData struct {
+	// Mapping defines the outputs of the node: each key is an output name and each value a JSON
+	// value whose strings may hold templates. A string made of a single template keeps the type of
+	// its result, so outputs can be numbers, arrays or nested objects, e.g.
+	// {"total": "{{sum(pluck(cart.items, \"price\"))}}", "body": {"ids": "{{join(ids, \",\")}}"}}.
+	Mapping map[string]interface{} `json:"mapping"`
+}
+
+// TransformNode computes values from flow inputs and upstream outputs without making any request.
+// Expressions can only read data and call the built-in template functions.
+type TransformNode struct {
+	BaseNode
+
+	Data TransformData `json:"data"`
+}
+
+// AsTransformNode safely casts an AnyNode to a TransformNode
+// Returns the TransformNode and true if the cast succeeds, nil and false otherwise.
+func AsTransformNode(node AnyNode) (*TransformNode, bool) {
+	transformNode, ok := node.(*TransformNode)
+	return transformNode, ok
+}
+
+// MustAsTransformNode casts an AnyNode to a TransformNode, panicking if it fails
+// Use this when you're certain the node is a TransformNode.
+func MustAsTransformNode(node AnyNode) *TransformNode {
+	transformNode, ok := AsTransformNode(node)
+	if !ok {
+		panic("expected TransformNode but got different type")
+	}
+	return transformNode
+}
+
+func (n *TransformNode) GetData() TransformData {
+	return n.Data
+}
+
+// InputSchema infers inputs from template variables in the mapping.
+func (n *TransformNode) InputSchema() []string {
+	si := &SchemaInference{}
+	return si.InferTransformNodeInputSchema(n.Data)
+}
+
+// OutputSchema returns the keys of the mapping, sorted.
+func (n *TransformNode) OutputSchema() []string {
+	outputs := make([]string, 0, len(n.Data.Mapping))
+	for name := range n.Data.Mapping {
+		outputs = append(outputs, name)
+	}
+	slices.Sort(outputs)
+	return outputs
+}
+
+// Execute evaluates the mapping and returns the computed values as outputs.
+func (n *TransformNode) Execute(ctx ExecutionContext) (AnyExecutionResult, error) {
+	startTime := time.Now()
+
+	loggerOf(ctx).Debug().
+		Str("nodeID", n.GetID()).
+		Any("inputs", ctx.Inputs).
+		Msg("Starting transform node execution")
+
+	result := &TransformExecutionResult{
+		BaseExecutionResult: BaseExecutionResult{
+			NodeID:      n.GetID(),
+			DisplayName: n.GetDisplayName(),
+			NodeType:    TypeTransform,
+			Inputs:      ctx.Inputs,
+		},
+	}
+
+	outputs, err := n.evaluateMapping(ctx)
+	result.DurationMs = time.Since(startTime).Milliseconds()
+	result.ExecutedAt = time.Now()
+	if err != nil {
+		errMsg := err.Error()
+		errCode := ErrorCodeTransformFailed
+		var unresolvedErr *UnresolvedTemplateError
+		if errors.As(err, &unresolvedErr) {
+			errCode = ErrorCodeUnresolvedTemplate
+		}
+		result.Error = err
+		result.ErrorMsg = &errMsg
+		result.ErrorCode = &errCode
+
+		loggerOf(ctx).Error().
+			Str("nodeID", n.GetID()).
+			Err(err).
+			Msg("Transform node failed")
+		return result, err
+	}
+	result.Outputs = outputs
+
+	loggerOf(ctx).Info().
+		Str("nodeID", n.GetID()).
+		Int("outputCount", len(outputs)).
+		Int64("durationMs", result.DurationMs).
+		Msg("Transform node executed successfully")
+
+	return result, nil
+}
+
+// evaluateMapping resolves every output of the mapping, in output name order.
+func (n *TransformNode) evaluateMapping(ctx ExecutionContext) (map[string]interface{}, error) {
+	resolver := newTemplateResolver(ctx)
+	outputs := make(map[string]interface{}, len(n.Data.Mapping))
+	for _, name := range n.OutputSchema() {
+		value, err := resolver.ResolveAt(n.Data.Mapping[name], "mapping."+name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate output '%s': %w", name, err)
+		}
+		outputs[name] = value
+	}
+	if err := checkUnresolvedTemplates(n.GetID(), resolver, ctx); err != nil {
+		return nil, err
+	}
+	return outputs, nil
+}
@@ -0,0 +1,108 @@
+package node_test
+
+import (
+	"testing"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/node"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func unmarshalTransformNode(t *testing.T, data string) *node.TransformNode {
+	t.Helper()
+	parsed, err := node.UnmarshalNode([]byte(data))
+	require.NoError(t, err)
+	return node.MustAsTransformNode(parsed)
+}
+
+func TestTransformNode_Execute(t *testing.T) {
+	transformNode := unmarshalTransformNode(
+		t, `{
+		"id": "summarize",
+		"type": "transform",
+		"data": {
+			"mapping": {
+				"ids": "{{join(list-orders.ids)}}",
+				"total": "{{sum(pluck(list-orders.orders, \"amount\"))}}",
+				"lastUpdate": "{{max(pluck(list-orders.orders, \"updatedAt\"))}}",
+				"body": {
+					"customer": {"id": "{{customerId}}", "label": "customer-{{customerId}}"},
+					"orderCount": "{{length(list-orders.ids)}}"
+				}
+			}
+		}
+	}`,
+	)
+	assert.Equal(t, []string{"customerId", "list-orders.ids", "list-orders.orders"}, transformNode.InputSchema())
+	assert.Equal(t, []string{"body", "ids", "lastUpdate", "total"}, transformNode.OutputSchema())
+
+	result, err := transformNode.Execute(
+		node.ExecutionContext{
+			Inputs: map[string]interface{}{
+				"customerId":      float64(42),
+				"list-orders.ids": []interface{}{"o1", "o2"},
+				"list-orders.orders": []interface{}{
+					map[string]interface{}{"amount": 12.5, "updatedAt": "2024-02-01T09:00:00Z"},
+					map[string]interface{}{"amount": float64(30), "updatedAt": "2024-03-15T18:20:00Z"},
+				},
+			},
+		},
+	)
+
+	require.NoError(t, err)
+	assert.Equal(
+		t, map[string]interface{}{
+			"ids":        "o1,o2",
+			"total":      42.5,
+			"lastUpdate": "2024-03-15T18:20:00Z",
+			"body": map[string]interface{}{
+				"customer":   map[string]interface{}{"id": float64(42), "label": "customer-42"},
+				"orderCount": 2,
+			},
+		}, result.GetOutputs(),
+	)
+	assert.Equal(t, node.TypeTransform, node.MustAsTransformExecutionResult(result).NodeType)
+}
+
+func TestTransformNode_Execute_Errors(t *testing.T) {
+	tests := []struct {
+		name         string
+		mapping      map[string]interface{}
+		expectedCode node.ErrorCode
+		expectedErr  string
+	}{
+		{
+			name:         "unresolved template",
+			mapping:      map[string]interface{}{"total": "{{sum(missing.values)}}"},
+			expectedCode: node.ErrorCodeUnresolvedTemplate,
+			expectedErr:  "missing.values (mapping.total)",
+		},
+		{
+			name:         "function error",
+			mapping:      map[string]interface{}{"total": "{{sum(names)}}"},
+			expectedCode: node.ErrorCodeTransformFailed,
+			expectedErr:  `failed to evaluate output 'total': `,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				transformNode := &node.TransformNode{
+					BaseNode: node.BaseNode{ID: "summarize", NodeType: node.TypeTransform},
+					Data:     node.TransformData{Mapping: tt.mapping},
+				}
+
+				result, err := transformNode.Execute(
+					node.ExecutionContext{Inputs: map[string]interface{}{"names": []interface{}{"a"}}},
+				)
+
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				transformResult := node.MustAsTransformExecutionResult(result)
+				assert.Equal(t, tt.expectedCode, *transformResult.ErrorCode)
+				assert.Empty(t, transformResult.Outputs)
+			},
+		)
+	}
+}
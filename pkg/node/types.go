@@ -1,6 +1,16 @@
 package node
 
-import "time"
+import (
+	"context"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/redact"
+)
 
 type AnyNode interface {
 	GetID() string
@@ -26,6 +36,23 @@ type AnyNode interface {
 	Execute(ctx ExecutionContext) (AnyExecutionResult, error)
 }
 
+// Prioritized is implemented by nodes that declare a scheduling priority. When several nodes
+// are ready to run, higher priorities start first; ties keep the declaration order of the flow.
+type Prioritized interface {
+	GetPriority() int
+}
+
+// Brancher is implemented by nodes whose outgoing edges may name a branch, see edge.Edge.Branch.
+type Brancher interface {
+	BranchNames() []string
+}
+
+// BranchSelector is implemented by the execution results of Brancher nodes. An outgoing edge
+// naming a branch is only taken when the result selects that branch.
+type BranchSelector interface {
+	IsBranchSelected(branch string) bool
+}
+
 type TypeNode[T any] interface {
 	AnyNode
 	GetData() T
@@ -34,12 +61,20 @@ type TypeNode[T any] interface {
 type Type string
 
 const (
-	TypeRequest Type = "request"
-	TypeDelay   Type = "delay"
+	TypeRequest   Type = "request"
+	TypeDelay     Type = "delay"
+	TypeForEach   Type = "forEach"
+	TypeCondition Type = "condition"
+	TypeTransform Type = "transform"
+	TypeSubflow   Type = "subflow"
+	TypePoll      Type = "poll"
 )
 
 // ExecutionContext provides inputs and context for a node's execution.
 type ExecutionContext struct {
+	// Context carries cancellation and deadlines of the flow run.
+	// Nodes must stop their work when it is done. A nil Context means context.Background().
+	Context context.Context
 	// Inputs contains all the data this node declared it needs in InputSchema()
 	// Keys are in format "nodeId.outputKey" (e.g., "create-user.userId")
 	Inputs map[string]interface{}
@@ -47,6 +82,47 @@ type ExecutionContext struct {
 	// Structure: map[nodeID]map[outputKey]value
 	// (for advanced use cases like conditional data passing)
 	AllOutputs map[string]map[string]interface{}
+	// LenientTemplates leaves templates referencing unknown variables in place instead of
+	// failing the node with an UnresolvedTemplateError.
+	LenientTemplates bool
+	// HTTPClient is shared by all request nodes of a run so that connections are reused.
+	// A nil HTTPClient means http.DefaultClient.
+	HTTPClient *http.Client
+	// TokenCache shares OAuth2 access tokens between the request nodes of a run.
+	// A nil TokenCache fetches a token for every request.
+	TokenCache *TokenCache
+	// Redactor holds the secret values of the run. Nodes register the secrets they produce,
+	// such as secret outputs and auth credentials. A nil Redactor masks nothing.
+	Redactor *redact.Redactor
+	// Logger is the logger of the run, whose output masks the secrets of the Redactor.
+	// A nil Logger means the global zerolog logger.
+	Logger *zerolog.Logger
+	// RunSubflow runs the subflow of a Container node. The engine only sets it for such nodes.
+	RunSubflow SubflowRunner
+}
+
+// loggerOf returns the logger of an execution, defaulting to the global zerolog logger.
+func loggerOf(ctx ExecutionContext) *zerolog.Logger {
+	if ctx.Logger == nil {
+		return &log.Logger
+	}
+	return ctx.Logger
+}
+
+// httpClientOf returns the HTTP client of an execution, defaulting to http.DefaultClient.
+func httpClientOf(ctx ExecutionContext) *http.Client {
+	if ctx.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return ctx.HTTPClient
+}
+
+// contextOf returns the cancellation context of an execution, defaulting to context.Background().
+func contextOf(ctx ExecutionContext) context.Context {
+	if ctx.Context == nil {
+		return context.Background()
+	}
+	return ctx.Context
 }
 
 // AnyExecutionResult is the interface for all execution results (polymorphic).
@@ -59,8 +135,10 @@ type AnyExecutionResult interface {
 	GetError() error
 	GetExecutedAt() time.Time
 
-	// Internal method to prevent external implementations
+	// Internal methods to prevent external implementations
 	isExecutionResult()
+	redact(redactor *redact.Redactor)
+	restoreError()
 }
 
 // BaseExecutionResult provides common fields for all execution results.
@@ -115,6 +193,12 @@ type RequestExecutionResult struct {
 	ResponseBody       []byte              `json:"response_body,omitempty"`
 	ResponseBodyParsed interface{}         `json:"response_body_parsed,omitempty"`
 
+	// Assertions evaluated against the response
+	AssertionResults []AssertionResult `json:"assertion_results,omitempty"`
+
+	// Every HTTP attempt made, including retries
+	Attempts []RequestAttempt `json:"attempts,omitempty"`
+
 	// Timing
 	DurationMs int64 `json:"duration_ms"`
 }
@@ -127,6 +211,82 @@ type DelayExecutionResult struct {
 	DelayUntil time.Time `json:"delay_until"`
 }
 
+// ForEachExecutionResult stores forEach node execution data.
+type ForEachExecutionResult struct {
+	BaseExecutionResult
+
+	ItemCount  int               `json:"item_count"`
+	Iterations []IterationResult `json:"iterations"`
+	DurationMs int64             `json:"duration_ms"`
+}
+
+// IterationResult is the run of a forEach subflow for a single item.
+type IterationResult struct {
+	Index  int                  `json:"index"`
+	Item   interface{}          `json:"item"`
+	Result *FlowExecutionResult `json:"result"`
+}
+
+// ConditionExecutionResult stores condition node execution data.
+type ConditionExecutionResult struct {
+	BaseExecutionResult
+
+	// Evaluations lists the branches evaluated, in order. Evaluation stops at the first match
+	// unless the node matches all branches.
+	Evaluations      []BranchEvaluation `json:"evaluations"`
+	SelectedBranches []string           `json:"selected_branches"`
+}
+
+// IsBranchSelected reports whether the condition selected the branch.
+func (r *ConditionExecutionResult) IsBranchSelected(branch string) bool {
+	return slices.Contains(r.SelectedBranches, branch)
+}
+
+// BranchEvaluation records the outcome of the conditions of a branch. Conditions are evaluated
+// in order until one does not hold.
+type BranchEvaluation struct {
+	Branch     string            `json:"branch"`
+	Matched    bool              `json:"matched"`
+	Conditions []AssertionResult `json:"conditions"`
+}
+
+// TransformExecutionResult stores transform node execution data.
+type TransformExecutionResult struct {
+	BaseExecutionResult
+
+	DurationMs int64 `json:"duration_ms"`
+}
+
+// SubflowExecutionResult stores subflow node execution data.
+type SubflowExecutionResult struct {
+	BaseExecutionResult
+
+	// Flow is the referenced child flow, nil when the flow is embedded in the node.
+	Flow       *FlowReference       `json:"flow,omitempty"`
+	Result     *FlowExecutionResult `json:"result"`
+	DurationMs int64                `json:"duration_ms"`
+}
+
+// PollExecutionResult stores poll node execution data. The request and response fields describe
+// the last poll, and Attempts the attempts made by its request.
+type PollExecutionResult struct {
+	RequestExecutionResult
+
+	Polls []PollAttempt `json:"polls"`
+}
+
+// PollAttempt records a single poll and the evaluation of the stop condition on its response.
+type PollAttempt struct {
+	Attempt      int               `json:"attempt"`
+	StatusCode   int               `json:"status_code,omitempty"`
+	Error        *string           `json:"error,omitempty"`
+	Conditions   []AssertionResult `json:"conditions,omitempty"`
+	ConditionMet bool              `json:"condition_met"`
+	DelayMs      int64             `json:"delay_ms,omitempty"` // Wait before the next poll
+	StartedAt    time.Time         `json:"started_at"`
+	DurationMs   int64             `json:"duration_ms"`
+}
+
 // AsRequestExecutionResult safely casts an AnyExecutionResult to a RequestExecutionResult.
 func AsRequestExecutionResult(result AnyExecutionResult) (*RequestExecutionResult, bool) {
 	reqResult, ok := result.(*RequestExecutionResult)
@@ -157,13 +317,119 @@ func MustAsDelayExecutionResult(result AnyExecutionResult) *DelayExecutionResult
 	return delayResult
 }
 
+// AsForEachExecutionResult safely casts an AnyExecutionResult to a ForEachExecutionResult.
+func AsForEachExecutionResult(result AnyExecutionResult) (*ForEachExecutionResult, bool) {
+	forEachResult, ok := result.(*ForEachExecutionResult)
+	return forEachResult, ok
+}
+
+// MustAsForEachExecutionResult casts an AnyExecutionResult to a ForEachExecutionResult, panicking if it fails.
+func MustAsForEachExecutionResult(result AnyExecutionResult) *ForEachExecutionResult {
+	forEachResult, ok := AsForEachExecutionResult(result)
+	if !ok {
+		panic("expected ForEachExecutionResult but got different type")
+	}
+	return forEachResult
+}
+
+// AsConditionExecutionResult safely casts an AnyExecutionResult to a ConditionExecutionResult.
+func AsConditionExecutionResult(result AnyExecutionResult) (*ConditionExecutionResult, bool) {
+	conditionResult, ok := result.(*ConditionExecutionResult)
+	return conditionResult, ok
+}
+
+// MustAsConditionExecutionResult casts an AnyExecutionResult to a ConditionExecutionResult,
+// panicking if it fails.
+func MustAsConditionExecutionResult(result AnyExecutionResult) *ConditionExecutionResult {
+	conditionResult, ok := AsConditionExecutionResult(result)
+	if !ok {
+		panic("expected ConditionExecutionResult but got different type")
+	}
+	return conditionResult
+}
+
+// AsTransformExecutionResult safely casts an AnyExecutionResult to a TransformExecutionResult.
+func AsTransformExecutionResult(result AnyExecutionResult) (*TransformExecutionResult, bool) {
+	transformResult, ok := result.(*TransformExecutionResult)
+	return transformResult, ok
+}
+
+// MustAsTransformExecutionResult casts an AnyExecutionResult to a TransformExecutionResult,
+// panicking if it fails.
+func MustAsTransformExecutionResult(result AnyExecutionResult) *TransformExecutionResult {
+	transformResult, ok := AsTransformExecutionResult(result)
+	if !ok {
+		panic("expected TransformExecutionResult but got different type")
+	}
+	return transformResult
+}
+
+// AsSubflowExecutionResult safely casts an AnyExecutionResult to a SubflowExecutionResult.
+func AsSubflowExecutionResult(result AnyExecutionResult) (*SubflowExecutionResult, bool) {
+	subflowResult, ok := result.(*SubflowExecutionResult)
+	return subflowResult, ok
+}
+
+// MustAsSubflowExecutionResult casts an AnyExecutionResult to a SubflowExecutionResult,
+// panicking if it fails.
+func MustAsSubflowExecutionResult(result AnyExecutionResult) *SubflowExecutionResult {
+	subflowResult, ok := AsSubflowExecutionResult(result)
+	if !ok {
+		panic("expected SubflowExecutionResult but got different type")
+	}
+	return subflowResult
+}
+
+// AsPollExecutionResult safely casts an AnyExecutionResult to a PollExecutionResult.
+func AsPollExecutionResult(result AnyExecutionResult) (*PollExecutionResult, bool) {
+	pollResult, ok := result.(*PollExecutionResult)
+	return pollResult, ok
+}
+
+// MustAsPollExecutionResult casts an AnyExecutionResult to a PollExecutionResult, panicking if it fails.
+func MustAsPollExecutionResult(result AnyExecutionResult) *PollExecutionResult {
+	pollResult, ok := AsPollExecutionResult(result)
+	if !ok {
+		panic("expected PollExecutionResult but got different type")
+	}
+	return pollResult
+}
+
+// ResultSchemaVersion is the version of the JSON schema of FlowExecutionResult. It changes when
+// a change of the schema prevents persisted results from being read back.
+const ResultSchemaVersion = "1"
+
+// NodeStatus is the outcome of a node in a flow run.
+type NodeStatus string
+
+const (
+	NodeStatusSucceeded  NodeStatus = "succeeded"
+	NodeStatusFailed     NodeStatus = "failed"      // Including failures routed along failure edges
+	NodeStatusSkipped    NodeStatus = "skipped"     // None of the incoming edges of the node was taken
+	NodeStatusCancelled  NodeStatus = "cancelled"   // Interrupted while running because the run was cancelled or aborted
+	NodeStatusNotStarted NodeStatus = "not_started" // Never started because the run was aborted or an upstream node failed
+)
+
 // FlowExecutionResult contains the complete trace of a flow execution.
+// It round-trips through JSON: execution results are decoded according to their node type.
 type FlowExecutionResult struct {
-	ExecutionResults map[string]AnyExecutionResult `json:"execution_results"` // Polymorphic results!
-	FinalOutputs     map[string]interface{}        `json:"final_outputs"`     // All outputs flattened for convenience (format: "nodeId.outputKey": value)
+	SchemaVersion    string                        `json:"schema_version"`
+	ExecutionResults map[string]AnyExecutionResult `json:"execution_results"`           // Polymorphic results!
+	Outputs          map[string]interface{}        `json:"outputs,omitempty"`           // Outputs declared by the flow, by public name; set when the flow succeeds
+	FinalOutputs     map[string]interface{}        `json:"final_outputs,omitempty"`     // Debugging only: "nodeId.outputKey" node outputs, unset if the flow declares outputs
+	SkippedNodes     []string                      `json:"skipped_nodes,omitempty"`     // Nodes not executed because none of their incoming edges was taken
+	CancelledNodes   []string                      `json:"cancelled_nodes,omitempty"`   // Nodes interrupted while running because the flow was cancelled or aborted
+	NotStartedNodes  []string                      `json:"not_started_nodes,omitempty"` // Nodes never started because the flow was cancelled or aborted
+	NodeStatuses     map[string]NodeStatus         `json:"node_statuses,omitempty"`     // Status of every node by node ID, unset when the run never started its nodes
 	Success          bool                          `json:"success"`
 	Error            error                         `json:"-"`
 	ErrorCode        *string                       `json:"error_code,omitempty"`
 	ErrorMsg         *string                       `json:"error_message,omitempty"`
 	DurationMS       int64                         `json:"duration_ms"`
 }
+
+// Output returns an output declared by the flow, by its public name. Node outputs are not exposed.
+func (r *FlowExecutionResult) Output(name string) (interface{}, bool) {
+	value, exists := r.Outputs[name]
+	return value, exists
+}
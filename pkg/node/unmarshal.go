@@ -27,6 +27,36 @@ func UnmarshalNode(data []byte) (AnyNode, error) {
 			return nil, fmt.Errorf("failed to unmarshal delay node: %w", err)
 		}
 		return &node, nil
+	case TypeForEach:
+		var node ForEachNode
+		if err := json.Unmarshal(data, &node); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal forEach node: %w", err)
+		}
+		return &node, nil
+	case TypeCondition:
+		var node ConditionNode
+		if err := json.Unmarshal(data, &node); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal condition node: %w", err)
+		}
+		return &node, nil
+	case TypeTransform:
+		var node TransformNode
+		if err := json.Unmarshal(data, &node); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal transform node: %w", err)
+		}
+		return &node, nil
+	case TypeSubflow:
+		var node SubflowNode
+		if err := json.Unmarshal(data, &node); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal subflow node: %w", err)
+		}
+		return &node, nil
+	case TypePoll:
+		var node PollNode
+		if err := json.Unmarshal(data, &node); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal poll node: %w", err)
+		}
+		return &node, nil
 	default:
 		return nil, fmt.Errorf("unknown node type: %s", peek.Type)
 	}
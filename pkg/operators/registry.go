@@ -0,0 +1,108 @@
+package operators
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+//nolint:gochecknoglobals
+var (
+	operatorRegistry = make(map[OperatorType]func([]byte) (Operator, error))
+	registryMutex    sync.RWMutex
+)
+
+// RegisterOperator registers a factory function for a custom operator type.
+// Built-in operator types are always decoded by UnmarshalOperator and cannot be overridden.
+func RegisterOperator(opType OperatorType, factory func([]byte) (Operator, error)) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	operatorRegistry[opType] = factory
+}
+
+// UnmarshalOperator creates an appropriate Operator from raw JSON.
+// The JSON must carry a "type" discriminator, e.g. {"type": "between", "min": 1, "max": 5}.
+func UnmarshalOperator(data []byte) (Operator, error) {
+	var peek struct {
+		Type OperatorType `json:"type"`
+	}
+
+	if err := json.Unmarshal(data, &peek); err != nil {
+		return nil, fmt.Errorf("failed to peek operator type: %w", err)
+	}
+
+	switch peek.Type {
+	case OperatorTypeEquals:
+		return decodeOperator[EqualsOperator](peek.Type, data)
+	case OperatorTypeNotEquals:
+		return decodeOperator[NotEqualsOperator](peek.Type, data)
+	case OperatorTypeContains:
+		return decodeOperator[ContainsOperator](peek.Type, data)
+	case OperatorTypeNotContains:
+		return decodeOperator[NotContainsOperator](peek.Type, data)
+	case OperatorTypeStartsWith:
+		return decodeOperator[StartsWithOperator](peek.Type, data)
+	case OperatorTypeEndsWith:
+		return decodeOperator[EndsWithOperator](peek.Type, data)
+	case OperatorTypeRegex:
+		return decodeOperator[RegexOperator](peek.Type, data)
+	case OperatorTypeEmpty:
+		return decodeOperator[EmptyOperator](peek.Type, data)
+	case OperatorTypeNotEmpty:
+		return decodeOperator[NotEmptyOperator](peek.Type, data)
+	case OperatorTypeGreaterThan:
+		return decodeOperator[GreaterThanOperator](peek.Type, data)
+	case OperatorTypeLessThan:
+		return decodeOperator[LessThanOperator](peek.Type, data)
+	case OperatorTypeGreaterThanOrEqual:
+		return decodeOperator[GreaterThanOrEqualOperator](peek.Type, data)
+	case OperatorTypeLessThanOrEqual:
+		return decodeOperator[LessThanOrEqualOperator](peek.Type, data)
+	case OperatorTypeBetween:
+		return decodeOperator[BetweenOperator](peek.Type, data)
+	default:
+		// Custom operators registered by third-party packages
+		registryMutex.RLock()
+		factory, ok := operatorRegistry[peek.Type]
+		registryMutex.RUnlock()
+		if ok {
+			return factory(data)
+		}
+		return nil, fmt.Errorf("unknown operator type: %s", peek.Type)
+	}
+}
+
+// MarshalOperator serializes an Operator to JSON, adding the "type" discriminator
+// so the result can be read back with UnmarshalOperator.
+func MarshalOperator(op Operator) ([]byte, error) {
+	if op == nil {
+		return nil, errors.New("cannot marshal nil operator")
+	}
+
+	data, err := json.Marshal(op)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s operator: %w", op.GetType(), err)
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if unmarshalErr := json.Unmarshal(data, &fields); unmarshalErr != nil {
+		return nil, fmt.Errorf("operator %s must marshal to a JSON object: %w", op.GetType(), unmarshalErr)
+	}
+
+	typeData, err := json.Marshal(op.GetType())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal operator type: %w", err)
+	}
+	fields["type"] = typeData
+
+	return json.Marshal(fields)
+}
+
+func decodeOperator[T Operator](opType OperatorType, data []byte) (Operator, error) {
+	var operator T
+	if err := json.Unmarshal(data, &operator); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s operator: %w", opType, err)
+	}
+	return operator, nil
+}
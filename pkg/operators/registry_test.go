@@ -0,0 +1,106 @@
+package operators_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/operators"
+)
+
+const operatorTypeLengthEquals operators.OperatorType = "lengthEquals"
+
+// lengthEqualsOperator is a custom operator used to test registry support for third-party operators.
+type lengthEqualsOperator struct {
+	Length int `json:"length"`
+}
+
+func (o lengthEqualsOperator) Validate(actual interface{}) (bool, error) {
+	actualStr, ok := actual.(string)
+	if !ok {
+		return false, fmt.Errorf("lengthEquals operator requires string, got %T", actual)
+	}
+	return len(actualStr) == o.Length, nil
+}
+
+func (o lengthEqualsOperator) GetType() operators.OperatorType {
+	return operatorTypeLengthEquals
+}
+
+func TestUnmarshalOperator_BuiltIn(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     string
+		expected operators.Operator
+	}{
+		{"Equals", `{"type": "equals", "expected": "ok"}`, operators.EqualsOperator{Expected: "ok"}},
+		{"Between", `{"type": "between", "min": 1, "max": 5}`, operators.BetweenOperator{Min: 1, Max: 5}},
+		{"Contains", `{"type": "contains", "substring": "json"}`, operators.ContainsOperator{Substring: "json"}},
+		{"Regex", `{"type": "regex", "pattern": "^a$"}`, operators.RegexOperator{Pattern: "^a$"}},
+		{"NotEmpty", `{"type": "notEmpty"}`, operators.NotEmptyOperator{}},
+		{"GreaterThan", `{"type": "greaterThan", "expected": 10}`, operators.GreaterThanOperator{Expected: 10}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(
+			tc.name, func(t *testing.T) {
+				op, err := operators.UnmarshalOperator([]byte(tc.data))
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, op)
+			},
+		)
+	}
+}
+
+func TestUnmarshalOperator_UnknownType(t *testing.T) {
+	op, err := operators.UnmarshalOperator([]byte(`{"type": "doesNotExist"}`))
+	require.Error(t, err)
+	assert.Nil(t, op)
+	assert.Contains(t, err.Error(), "unknown operator type")
+}
+
+func TestUnmarshalOperator_InvalidJSON(t *testing.T) {
+	op, err := operators.UnmarshalOperator([]byte(`{"type": "between", "min": "one"}`))
+	require.Error(t, err)
+	assert.Nil(t, op)
+}
+
+func TestRegisterOperator_CustomOperator(t *testing.T) {
+	operators.RegisterOperator(
+		operatorTypeLengthEquals, func(data []byte) (operators.Operator, error) {
+			var op lengthEqualsOperator
+			if err := json.Unmarshal(data, &op); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal lengthEquals operator: %w", err)
+			}
+			return op, nil
+		},
+	)
+
+	op, err := operators.UnmarshalOperator([]byte(`{"type": "lengthEquals", "length": 5}`))
+	require.NoError(t, err)
+	assert.Equal(t, lengthEqualsOperator{Length: 5}, op)
+
+	result, err := op.Validate("hello")
+	require.NoError(t, err)
+	assert.True(t, result)
+}
+
+func TestMarshalOperator_RoundTrip(t *testing.T) {
+	original := operators.BetweenOperator{Min: 200, Max: 299}
+
+	data, err := operators.MarshalOperator(original)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type": "between", "min": 200, "max": 299}`, string(data))
+
+	decoded, err := operators.UnmarshalOperator(data)
+	require.NoError(t, err)
+	assert.Equal(t, original, decoded)
+}
+
+func TestMarshalOperator_Nil(t *testing.T) {
+	_, err := operators.MarshalOperator(nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,274 @@
+package redact
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// Mask replaces every occurrence of a secret value.
+const Mask = "***"
+
+// minEmbeddedLength is the length from which secrets are also masked inside longer text. Shorter
+// secrets, such as small numbers, would rewrite unrelated text and break the structure of log lines.
+const minEmbeddedLength = 6
+
+// Redactor masks registered secret values wherever they appear in strings, including as part of
+// longer strings such as "Bearer <token>". Secrets shorter than six characters are only masked where
+// they make up a whole value, or a whole JSON string value in log lines. It is safe for concurrent
+// use; all methods accept a nil Redactor, which redacts nothing.
+type Redactor struct {
+	mu      sync.RWMutex
+	secrets []string // longest first, so that a secret containing another one is masked as a whole
+}
+
+// New creates a Redactor masking the given values, see Add.
+func New(values ...interface{}) *Redactor {
+	r := &Redactor{}
+	for _, value := range values {
+		r.Add(value)
+	}
+	return r
+}
+
+// Add registers a secret value. Strings and numbers are masked by their text; maps and slices
+// register every value they contain.
+func (r *Redactor) Add(value interface{}) {
+	if r == nil {
+		return
+	}
+	var collected []string
+	collectSecrets(value, &collected)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, secret := range collected {
+		if secret == "" || slices.Contains(r.secrets, secret) {
+			continue
+		}
+		r.secrets = append(r.secrets, secret)
+		// Secrets also appear JSON escaped in logs and serialized results
+		if escaped := jsonEscape(secret); escaped != secret && !slices.Contains(r.secrets, escaped) {
+			r.secrets = append(r.secrets, escaped)
+		}
+	}
+	slices.SortFunc(r.secrets, func(a, b string) int { return cmp.Compare(len(b), len(a)) })
+}
+
+// Empty reports whether no secret is registered.
+func (r *Redactor) Empty() bool {
+	if r == nil {
+		return true
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.secrets) == 0
+}
+
+// String masks the secrets contained in s.
+func (r *Redactor) String(s string) string {
+	if r == nil {
+		return s
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if slices.Contains(r.secrets, s) {
+		return Mask
+	}
+	for _, secret := range r.secrets {
+		if len(secret) >= minEmbeddedLength {
+			s = strings.ReplaceAll(s, secret, Mask)
+		}
+	}
+	return s
+}
+
+// Bytes masks the secrets contained in b, such as a log line. Short secrets are masked where they
+// are a quoted JSON string value, as in {"apiKey":"k9x2"}. The input is not modified.
+func (r *Redactor) Bytes(b []byte) []byte {
+	if r == nil {
+		return b
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, secret := range r.secrets {
+		if len(secret) >= minEmbeddedLength {
+			b = bytes.ReplaceAll(b, []byte(secret), []byte(Mask))
+		} else {
+			b = maskQuotedValue(b, secret)
+		}
+	}
+	return b
+}
+
+// maskQuotedValue masks the JSON string values of b equal to secret. Object keys and quotes escaped
+// within a longer string are left as they are.
+func maskQuotedValue(b []byte, secret string) []byte {
+	quoted := []byte(`"` + secret + `"`)
+	var masked []byte
+	rest := b
+	for {
+		index := bytes.Index(rest, quoted)
+		if index < 0 {
+			break
+		}
+		end := index + len(quoted)
+		escaped := index > 0 && rest[index-1] == '\\'
+		isKey := end < len(rest) && rest[end] == ':'
+		masked = append(masked, rest[:index]...)
+		if escaped || isKey {
+			masked = append(masked, rest[index:end]...)
+		} else {
+			masked = append(masked, `"`+Mask+`"`...)
+		}
+		rest = rest[end:]
+	}
+	if masked == nil {
+		return b
+	}
+	return append(masked, rest...)
+}
+
+// isSecret reports whether text is exactly a registered secret.
+func (r *Redactor) isSecret(text string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Contains(r.secrets, text)
+}
+
+// Value returns a copy of value with the secrets masked in every string it contains.
+// A number equal to a secret is replaced by Mask.
+func (r *Redactor) Value(value interface{}) interface{} {
+	if r.Empty() {
+		return value
+	}
+	switch v := value.(type) {
+	case string:
+		return r.String(v)
+	case map[string]interface{}:
+		return r.Map(v)
+	case map[string]string:
+		return r.StringMap(v)
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, item := range v {
+			redacted[i] = r.Value(item)
+		}
+		return redacted
+	case float64, int, int64:
+		if text, ok := scalarText(v); ok && r.isSecret(text) {
+			return Mask
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// Map returns a copy of m with the secrets masked in its values.
+func (r *Redactor) Map(m map[string]interface{}) map[string]interface{} {
+	if m == nil || r.Empty() {
+		return m
+	}
+	redacted := make(map[string]interface{}, len(m))
+	for key, item := range m {
+		redacted[key] = r.Value(item)
+	}
+	return redacted
+}
+
+// StringMap returns a copy of m with the secrets masked in its values.
+func (r *Redactor) StringMap(m map[string]string) map[string]string {
+	if m == nil || r.Empty() {
+		return m
+	}
+	redacted := make(map[string]string, len(m))
+	for key, item := range m {
+		redacted[key] = r.String(item)
+	}
+	return redacted
+}
+
+func collectSecrets(value interface{}, collected *[]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, item := range v {
+			collectSecrets(item, collected)
+		}
+	case map[string]string:
+		for _, item := range v {
+			*collected = append(*collected, item)
+		}
+	case []interface{}:
+		for _, item := range v {
+			collectSecrets(item, collected)
+		}
+	default:
+		if text, ok := scalarText(v); ok {
+			*collected = append(*collected, text)
+		}
+	}
+}
+
+func scalarText(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	default:
+		return "", false
+	}
+}
+
+// jsonEscape returns s as it appears inside a JSON string.
+func jsonEscape(s string) string {
+	encoded, err := json.Marshal(s)
+	if err != nil {
+		return s
+	}
+	return string(encoded[1 : len(encoded)-1])
+}
+
+// writer masks the secrets of a Redactor before writing.
+type writer struct {
+	out      io.Writer
+	redactor *Redactor
+}
+
+// NewWriter wraps a log output so that the secrets of r are masked. Every flow run logs through
+// its own writer, so that the secrets of one run never rewrite the logs of another.
+func NewWriter(out io.Writer, r *Redactor) io.Writer {
+	return writer{out: out, redactor: r}
+}
+
+func (w writer) Write(p []byte) (int, error) {
+	if _, err := w.out.Write(w.redactor.Bytes(p)); err != nil {
+		return 0, err
+	}
+	// Report the original length, writers must not return a count larger than len(p)
+	return len(p), nil
+}
+
+// WriteLevel keeps the level of zerolog events for outputs that route them by level.
+func (w writer) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	levelWriter, ok := w.out.(zerolog.LevelWriter)
+	if !ok {
+		return w.Write(p)
+	}
+	if _, err := levelWriter.WriteLevel(level, w.redactor.Bytes(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,112 @@
+package redact_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nanostack-dev/echopoint-flow-engine/pkg/redact"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactor_String(t *testing.T) {
+	redactor := redact.New("tok-123", "tok-123-long", `pa"ss-word`, "pin", float64(200))
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "whole value", input: "tok-123", expected: "***"},
+		{name: "embedded value", input: "Bearer tok-123", expected: "Bearer ***"},
+		{name: "longest secret first", input: "tok-123-long", expected: "***"},
+		{name: "JSON escaped value", input: `{"password":"pa\"ss-word"}`, expected: `{"password":"***"}`},
+		{name: "no secret", input: "public", expected: "public"},
+		{name: "whole short value", input: "pin", expected: "***"},
+		{name: "embedded short value", input: "spinning", expected: "spinning"},
+		{name: "embedded short number", input: "status 200 in 1200ms", expected: "status 200 in 1200ms"},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				assert.Equal(t, tt.expected, redactor.String(tt.input))
+			},
+		)
+	}
+}
+
+func TestRedactor_Bytes(t *testing.T) {
+	redactor := redact.New("tok-123", "k9x2", "id")
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "embedded value", input: `{"message":"Bearer tok-123"}`, expected: `{"message":"Bearer ***"}`},
+		{name: "short JSON value", input: `{"inputs":{"apiKey":"k9x2"}}`, expected: `{"inputs":{"apiKey":"***"}}`},
+		{name: "short array item", input: `{"keys":["k9x2","other"]}`, expected: `{"keys":["***","other"]}`},
+		{name: "short embedded value", input: `{"message":"key k9x2 used"}`, expected: `{"message":"key k9x2 used"}`},
+		{name: "short object key", input: `{"id":"id"}`, expected: `{"id":"***"}`},
+		{name: "short escaped quotes", input: `{"message":"say \"k9x2\""}`, expected: `{"message":"say \"k9x2\""}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				assert.Equal(t, tt.expected, string(redactor.Bytes([]byte(tt.input))))
+			},
+		)
+	}
+}
+
+func TestRedactor_Value(t *testing.T) {
+	redactor := redact.New(map[string]interface{}{"apiKey": "key-1", "pin": float64(1234), "code": float64(42)})
+	value := map[string]interface{}{
+		"headers": map[string]string{"X-Api-Key": "key-1"},
+		"items":   []interface{}{"key-1", float64(1234), float64(5), float64(1042), 420, int64(42)},
+		"name":    "alice",
+	}
+
+	redacted := redactor.Value(value)
+
+	assert.Equal(
+		t, map[string]interface{}{
+			"headers": map[string]string{"X-Api-Key": "***"},
+			"items":   []interface{}{"***", "***", float64(5), float64(1042), 420, "***"},
+			"name":    "alice",
+		}, redacted,
+	)
+	assert.Equal(t, "key-1", value["items"].([]interface{})[0], "the input should not be modified")
+}
+
+func TestRedactor_NilAndEmpty(t *testing.T) {
+	var nilRedactor *redact.Redactor
+	nilRedactor.Add("secret")
+
+	assert.True(t, nilRedactor.Empty())
+	assert.Equal(t, "secret", nilRedactor.String("secret"))
+	assert.True(t, redact.New("").Empty(), "empty values should not be registered")
+}
+
+func TestNewWriter(t *testing.T) {
+	var out bytes.Buffer
+	first := redact.NewWriter(&out, redact.New("s3cret-one", float64(1)))
+	second := redact.NewWriter(&out, redact.New("s3cret-two"))
+
+	line := `{"level":"info","count":1,"message":"s3cret-one s3cret-two"}` + "\n"
+	n, err := first.Write([]byte(line))
+	require.NoError(t, err)
+	assert.Equal(t, len(line), n)
+	_, err = second.Write([]byte(line))
+	require.NoError(t, err)
+
+	assert.Equal(
+		t,
+		`{"level":"info","count":1,"message":"*** s3cret-two"}`+"\n"+
+			`{"level":"info","count":1,"message":"s3cret-one ***"}`+"\n",
+		out.String(),
+		"each writer should only mask the secrets of its own redactor",
+	)
+}